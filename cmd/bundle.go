@@ -10,18 +10,26 @@ import (
 func BundleCmd() *cobra.Command {
 	cmd := &cobra.Command{}
 
-	cmd.Use = "bundle"
+	cmd.Use = "bundle [path]"
 	cmd.Short = "Bundle a Python project"
-	cmd.Long = `Bundle a Python project into a single executable file.`
+	cmd.Long = `Bundle a Python project into a single executable file.
 
-	cmd.Flags().StringP("path", "p", ".", "Path to the Python project")
+The path is either a project directory with a pyproject.toml or a single-file
+script with PEP 723 inline metadata. Use --wheel or --package to bundle an
+existing wheel or a published package instead. Inside a uv workspace,
+--package selects the members to bundle, and the entry points of several
+members are merged into one binary.
+
+With --offline, nothing is downloaded and every artifact is taken from the
+cache filled by the prefetch command.`
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	addSourceFlags(cmd)
 	cmd.Flags().StringP("output", "o", "", "Output directory for the bundle")
 	cmd.Flags().BoolP("overwrite", "w", false, "Overwrite existing files")
-	cmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+	cmd.Flags().Bool("offline", false, "Bundle without network access from the prefetched cache")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
-		// Implementation here
-		path := cmd.Flag("path").Value.String()
 		output := cmd.Flag("output").Value.String()
 		overwrite := cmd.Flag("overwrite").Value.String()
 		verbose := cmd.Flag("verbose").Value.String()
@@ -30,11 +38,134 @@ func BundleCmd() *cobra.Command {
 			slog.SetLogLoggerLevel(slog.LevelDebug)
 		}
 
-		b, err := bundle.New(path, output, overwrite == "true")
+		var cache *bundle.Cache
+		if cmd.Flag("offline").Value.String() == "true" {
+			var err error
+			cache, err = setCache(cmd, true)
+			cobra.CheckErr(err)
+		}
+		b, err := newBundle(cmd, args, output, overwrite == "true")
 		cobra.CheckErr(err)
+		b.Cache = cache
 		err = b.Run(verbose == "true")
 		cobra.CheckErr(err)
 	}
 
 	return cmd
 }
+
+// addSourceFlags adds the flags that select what is bundled and where its
+// dependencies come from.
+func addSourceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("path", "p", ".", "Path to the Python project or script")
+	cmd.Flags().String("wheel", "", "Bundle an existing wheel instead of a project")
+	cmd.Flags().StringArray("package", nil, "Bundle a workspace member or a published package, like name==version")
+	cmd.Flags().String("index-url", "", "Index to resolve and download dependencies from")
+	cmd.Flags().StringArray("extra-index-url", nil, "Additional index to resolve and download dependencies from")
+	cmd.Flags().StringArray("find-links", nil, "Directory or page with links to distributions")
+	cmd.Flags().String("cert", "", "CA bundle used to verify the indexes")
+	cmd.MarkFlagsMutuallyExclusive("path", "wheel")
+	cmd.MarkFlagsMutuallyExclusive("wheel", "package")
+	cmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+	cmd.Flags().String("separator", bundle.DEFAULT_COMMAND_SEPARATOR, "Separator that splits entry point names into nested commands")
+	cmd.Flags().StringArray("extra", nil, "Include an optional dependency extra in the bundle")
+	cmd.Flags().Bool("all-extras", false, "Include all optional dependency extras in the bundle")
+	cmd.Flags().StringArray("group", nil, "Include a dependency group in the bundle")
+	cmd.Flags().StringArray("platform", nil, "Platform to package the dependencies for, like linux-amd64 (default is every platform)")
+	cmd.Flags().StringArray("build-sdists", nil, "Build the wheel of a package with only a source distribution for the host platform")
+	cmd.Flags().Int("optimize", 0, "Optimization level of the precompiled bytecode, from 0 to 2")
+	cmd.Flags().String("go-embed-python", "", "Version of go-embed-python, which selects the embedded Python version (default is "+bundle.GO_EMBED_PYTHON_VERSION+")")
+	cmd.Flags().String("cache-dir", "", "Cache of the artifacts for offline bundling (default is pybundler in the user cache directory)")
+}
+
+// setCache configures every command run by the bundler to use the cache
+// given by --cache-dir.
+func setCache(cmd *cobra.Command, offline bool) (*bundle.Cache, error) {
+	dir := cmd.Flag("cache-dir").Value.String()
+	if dir == "" {
+		var err error
+		dir, err = bundle.DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+	cache := &bundle.Cache{Dir: dir, Offline: offline}
+	return cache, cache.Setenv()
+}
+
+// newBundle creates the bundle selected by the source flags.
+func newBundle(cmd *cobra.Command, args []string, output string, overwrite bool) (*bundle.BundleOptions, error) {
+	path := cmd.Flag("path").Value.String()
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	opts := make([]bundle.Option, 0)
+	if cmd.Flags().Changed("separator") {
+		opts = append(opts, bundle.WithCommandSeparator(cmd.Flag("separator").Value.String()))
+	}
+	extras, err := cmd.Flags().GetStringArray("extra")
+	cobra.CheckErr(err)
+	if len(extras) > 0 {
+		opts = append(opts, bundle.WithExtras(extras...))
+	}
+	if cmd.Flag("all-extras").Value.String() == "true" {
+		opts = append(opts, bundle.WithAllExtras())
+	}
+	groups, err := cmd.Flags().GetStringArray("group")
+	cobra.CheckErr(err)
+	if len(groups) > 0 {
+		opts = append(opts, bundle.WithGroups(groups...))
+	}
+	platforms, err := cmd.Flags().GetStringArray("platform")
+	cobra.CheckErr(err)
+	if len(platforms) > 0 {
+		opts = append(opts, bundle.WithPlatforms(platforms...))
+	}
+	sdists, err := cmd.Flags().GetStringArray("build-sdists")
+	cobra.CheckErr(err)
+	if len(sdists) > 0 {
+		opts = append(opts, bundle.WithBuildSdists(sdists...))
+	}
+	if cmd.Flags().Changed("optimize") {
+		level, err := cmd.Flags().GetInt("optimize")
+		cobra.CheckErr(err)
+		opts = append(opts, bundle.WithOptimize(level))
+	}
+	if v := cmd.Flag("go-embed-python").Value.String(); v != "" {
+		opts = append(opts, bundle.WithGoEmbedPython(v))
+	}
+	index := bundle.IndexSection{
+		IndexURL: cmd.Flag("index-url").Value.String(),
+		Cert:     cmd.Flag("cert").Value.String(),
+	}
+	index.ExtraIndexURL, err = cmd.Flags().GetStringArray("extra-index-url")
+	cobra.CheckErr(err)
+	index.FindLinks, err = cmd.Flags().GetStringArray("find-links")
+	cobra.CheckErr(err)
+	index, err = index.Resolve(".")
+	cobra.CheckErr(err)
+	opts = append(opts, bundle.WithIndex(index))
+
+	wheel := cmd.Flag("wheel").Value.String()
+	pkgs, err := cmd.Flags().GetStringArray("package")
+	cobra.CheckErr(err)
+	switch {
+	case len(args) > 0 && wheel != "":
+		cobra.CheckErr("a path cannot be combined with --wheel")
+	case wheel != "":
+		return bundle.NewFromWheel(wheel, output, overwrite, opts...)
+	case len(pkgs) > 0:
+		ws, err := bundle.FindWorkspace(path)
+		cobra.CheckErr(err)
+		switch {
+		case ws != nil && ws.HasMembers(pkgs):
+			return bundle.NewFromWorkspace(path, pkgs, output, overwrite, opts...)
+		case len(pkgs) == 1:
+			return bundle.NewFromPackage(pkgs[0], output, overwrite, opts...)
+		default:
+			cobra.CheckErr("several packages can only be bundled from the members of a uv workspace")
+		}
+	}
+	return bundle.New(path, output, overwrite, opts...)
+}
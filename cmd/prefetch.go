@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func PrefetchCmd() *cobra.Command {
+	cmd := &cobra.Command{}
+
+	cmd.Use = "prefetch [path]"
+	cmd.Short = "Download everything needed to bundle offline"
+	cmd.Long = `Download the Go modules, Python distributions, packages and wheels needed to
+bundle a Python project into the cache, so that it can be bundled with
+bundle --offline on a machine without network access. It takes the same
+arguments as bundle. Copy the cache directory to the offline machine and
+pass it with --cache-dir.`
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	addSourceFlags(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		verbose := cmd.Flag("verbose").Value.String()
+		if verbose == "true" {
+			slog.SetLogLoggerLevel(slog.LevelDebug)
+		}
+
+		cache, err := setCache(cmd, false)
+		cobra.CheckErr(err)
+		output, err := os.MkdirTemp("", "pybundler-prefetch-")
+		cobra.CheckErr(err)
+		defer os.RemoveAll(output)
+		b, err := newBundle(cmd, args, output, true)
+		cobra.CheckErr(err)
+		b.Cache = cache
+		err = b.Prefetch(verbose == "true")
+		cobra.CheckErr(err)
+	}
+
+	return cmd
+}
@@ -45,4 +45,5 @@ func init() {
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.AddCommand(BundleCmd())
+	rootCmd.AddCommand(PrefetchCmd())
 }
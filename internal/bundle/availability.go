@@ -0,0 +1,205 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// Availability is how a requirement can be installed on a platform.
+type Availability string
+
+const (
+	WHEEL        Availability = "wheel"
+	NO_WHEEL     Availability = "no wheel"
+	BUILT        Availability = "built"
+	NOT_REQUIRED Availability = "-"
+)
+
+// WheelMatrix is the availability of every pinned requirement on every
+// platform of a bundle.
+type WheelMatrix struct {
+	Platforms    []Platform
+	Requirements []*Requirement
+	Cells        [][]Availability
+
+	// Errs holds why a requirement cannot be installed from a wheel on a
+	// platform, for the cells without a wheel.
+	Errs [][]error
+}
+
+// NewWheelMatrix checks which platforms the pinned requirements in pkgReqs
+// can be installed on from wheels. install installs requirements for a
+// platform from wheels only, without their dependencies. The requirements
+// of each platform are installed together first, and one by one only when
+// that fails, to find the requirements without a wheel.
+func NewWheelMatrix(pkgReqs []byte, platforms []Platform, pythonVersion string, install func(reqs []*Requirement, p Platform) error) *WheelMatrix {
+	m := &WheelMatrix{Platforms: platforms}
+	for _, line := range RequirementLines(pkgReqs) {
+		if req, ok := ParseRequirement(line); ok {
+			m.Requirements = append(m.Requirements, req)
+		}
+	}
+	m.Cells = make([][]Availability, len(m.Requirements))
+	m.Errs = make([][]error, len(m.Requirements))
+	for i, req := range m.Requirements {
+		m.Cells[i] = make([]Availability, len(platforms))
+		m.Errs[i] = make([]error, len(platforms))
+		for j, p := range platforms {
+			m.Cells[i][j] = NOT_REQUIRED
+			if req.InstalledOn(p, pythonVersion) {
+				m.Cells[i][j] = WHEEL
+			}
+		}
+	}
+
+	failed := make([]bool, len(platforms))
+	parallel(len(platforms), func(j int) {
+		reqs := make([]*Requirement, 0)
+		for i, req := range m.Requirements {
+			if m.Cells[i][j] == WHEEL {
+				reqs = append(reqs, req)
+			}
+		}
+		failed[j] = len(reqs) > 0 && install(reqs, platforms[j]) != nil
+	})
+	cells := make([][2]int, 0)
+	for i := range m.Requirements {
+		for j := range platforms {
+			if failed[j] && m.Cells[i][j] == WHEEL {
+				cells = append(cells, [2]int{i, j})
+			}
+		}
+	}
+	parallel(len(cells), func(k int) {
+		i, j := cells[k][0], cells[k][1]
+		err := install(m.Requirements[i:i+1], platforms[j])
+		if err != nil {
+			m.Cells[i][j] = NO_WHEEL
+			m.Errs[i][j] = err
+		}
+	})
+	return m
+}
+
+// parallel calls f with 0 to n-1, at most 8 at a time.
+func parallel(n int, f func(i int)) {
+	sem := make(chan struct{}, 8)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			f(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// String returns the matrix as a table with a row per requirement and a
+// column per platform.
+func (m *WheelMatrix) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	header := []string{"PACKAGE"}
+	for _, p := range m.Platforms {
+		header = append(header, p.String())
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for i, req := range m.Requirements {
+		row := []string{req.Name + "==" + req.Version}
+		for _, cell := range m.Cells[i] {
+			row = append(row, string(cell))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return b.String()
+}
+
+// Err returns an error that lists every requirement without a wheel for a
+// platform it is installed on, with what can be done about it.
+func (m *WheelMatrix) Err() error {
+	problems := make([]string, 0)
+	for i, req := range m.Requirements {
+		for j, cell := range m.Cells[i] {
+			if cell != NO_WHEEL {
+				continue
+			}
+			problem := fmt.Sprintf("%s==%s has no wheel for %s", req.Name, req.Version, m.Platforms[j])
+			if reason := errorReason(m.Errs[i][j]); reason != "" {
+				problem += ": " + reason
+			}
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("dependencies are not available as wheels for every platform:\n  %s\npin versions of these packages that publish wheels for the platforms, select the platforms to bundle for with --platform, or allow building packages with only a source distribution for the host platform with --build-sdists", strings.Join(problems, "\n  "))
+}
+
+// errorReason returns the last line uv printed for a failed command, which
+// explains why it failed.
+func errorReason(err error) string {
+	var cmdErr *CmdError
+	if !errors.As(err, &cmdErr) {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(cmdErr.Stderr), "\n")
+	return strings.TrimLeft(strings.TrimSpace(lines[len(lines)-1]), "╰─▶ ")
+}
+
+// installWheels returns a function that installs requirements for a
+// platform from wheels with a dry run of `uv pip install`, so that uv
+// finds them with the indexes, credentials and certificates it is
+// configured with.
+func (bo *BundleOptions) installWheels(pythonVersion string, verbose bool) func(reqs []*Requirement, p Platform) error {
+	return func(reqs []*Requirement, p Platform) error {
+		dir, err := os.MkdirTemp("", "pybundler-wheels-")
+		if err != nil {
+			return fmt.Errorf("creating wheel check directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		lines := make([]string, 0, len(reqs))
+		for _, req := range reqs {
+			lines = append(lines, strings.Join(append([]string{req.String()}, req.Hashes...), " "))
+		}
+		input := filepath.Join(dir, "requirements.txt")
+		err = os.WriteFile(input, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+		if err != nil {
+			return fmt.Errorf("writing requirements: %v", err)
+		}
+		env := append(slices.Clone(bo.Env), p.Environ()...)
+		_, err = RunCmdWith(bo.Path, verbose, CmdOptions{Env: env}, "uv", "pip", "install", "--dry-run", "--no-deps",
+			"--requirements", input, "--target", filepath.Join(dir, "target"),
+			"--python-platform", p.UV, "--python-version", pythonVersion, "--only-binary", ":all:")
+		return err
+	}
+}
+
+// checkWheels checks with uv that every pinned requirement in pkgReqs has a
+// wheel for every platform of the bundle, builds the allowed wheels from
+// sdists and prints the availability matrix.
+func (bo *BundleOptions) checkWheels(pkgReqs []byte, verbose bool) ([]BuiltWheel, error) {
+	packaging, err := bo.Packaging()
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Checking wheel availability", "platforms", len(packaging.Platforms))
+	matrix := NewWheelMatrix(pkgReqs, packaging.Platforms, packaging.PythonVersion, bo.installWheels(packaging.PythonVersion, verbose))
+	built, err := bo.buildSdists(matrix, packaging.PythonVersion, verbose)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Print(matrix)
+	return built, matrix.Err()
+}
@@ -0,0 +1,76 @@
+package bundle_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestWheelMatrix(t *testing.T) {
+	reqs := `click==8.1.8 \
+    --hash=sha256:aa
+colorama==0.4.6 ; sys_platform == 'win32' \
+    --hash=sha256:bb
+numpy==2.3.0 \
+    --hash=sha256:cc \
+    --hash=sha256:dd
+`
+	wheels := map[string][]string{
+		"linux-amd64":   {"click", "numpy"},
+		"linux-arm64":   {"click"},
+		"windows-amd64": {"click", "colorama"},
+	}
+	var mu sync.Mutex
+	installs := 0
+	install := func(reqs []*bundle.Requirement, p bundle.Platform) error {
+		mu.Lock()
+		installs++
+		mu.Unlock()
+		for _, req := range reqs {
+			if !slices.Contains(wheels[p.String()], req.Name) {
+				return fmt.Errorf("no wheel for %s", req.Name)
+			}
+		}
+		return nil
+	}
+	platforms, err := bundle.SelectPlatforms([]string{"linux-arm64", "linux-amd64", "windows-amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matrix := bundle.NewWheelMatrix([]byte(reqs), platforms, "3.14.6", install)
+	want := [][]bundle.Availability{
+		{bundle.WHEEL, bundle.WHEEL, bundle.WHEEL},
+		{bundle.NOT_REQUIRED, bundle.NOT_REQUIRED, bundle.WHEEL},
+		{bundle.WHEEL, bundle.NO_WHEEL, bundle.NO_WHEEL},
+	}
+	for i, row := range want {
+		if !slices.Equal(matrix.Cells[i], row) {
+			t.Errorf("unexpected availability of %s: %v", matrix.Requirements[i].Name, matrix.Cells[i])
+		}
+	}
+	// Each platform is installed at once and requirements are only
+	// installed one by one on the platforms that failed.
+	if installs != 3+2+3 {
+		t.Errorf("unexpected number of installs %d", installs)
+	}
+	table := matrix.String()
+	if !strings.HasPrefix(table, "PACKAGE          linux-amd64  linux-arm64  windows-amd64\n") || !strings.Contains(table, "numpy==2.3.0     wheel        no wheel     no wheel\n") {
+		t.Fatalf("unexpected matrix:\n%s", table)
+	}
+	err = matrix.Err()
+	if err == nil || !strings.Contains(err.Error(), "numpy==2.3.0 has no wheel for linux-arm64\n") || !strings.Contains(err.Error(), "--platform") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	matrix = bundle.NewWheelMatrix([]byte(reqs), platforms, "3.14.6", func(reqs []*bundle.Requirement, p bundle.Platform) error {
+		return &bundle.CmdError{Err: fmt.Errorf("exit status 1"), Stderr: "  × No solution found when resolving dependencies:\n  ╰─▶ Because numpy==2.3.0 has no wheels with a matching platform tag, we can conclude that your requirements are unsatisfiable.\n"}
+	})
+	err = matrix.Err()
+	if err == nil || !strings.Contains(err.Error(), "numpy==2.3.0 has no wheel for linux-amd64: Because numpy==2.3.0 has no wheels with a matching platform tag") {
+		t.Fatalf("expected the reason from uv, got %v", err)
+	}
+}
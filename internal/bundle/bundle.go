@@ -1,11 +1,14 @@
 package bundle
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -18,25 +21,355 @@ type BundleOptions struct {
 	Output    string
 	PyProject *PyProject
 	Commands  *CommandCollection
+
+	// Script is the single-file script bundled instead of a project, if
+	// any. Path is then the directory of the script.
+	Script string
+
+	// Binary is the name of the executable that is built.
+	Binary string
+
+	// Package is the requirement of the wheel or published package bundled
+	// instead of a project, if any. Projects without a [project] table are
+	// bundled as their built wheel.
+	Package string
+
+	// Members are the uv workspace members bundled together, if more than
+	// one is selected. Path is then the workspace root.
+	Members []WorkspaceMember
+
+	// Wheel is the wheel built while reading the project metadata or the
+	// bundled wheel, if any. It is reused instead of building the project
+	// again.
+	Wheel string
+
+	// WheelDir is the temporary directory Wheel was built into, if any. It
+	// is removed once the wheel is moved to the output directory.
+	WheelDir string
+
+	// Cache holds the artifacts prefetched for offline bundling, if any.
+	Cache *Cache
+
+	// Env is added to the environment of every command run for the bundle,
+	// like the configuration of the indexes.
+	Env []string
+}
+
+// Option overrides the configuration read from [tool.pybundler].
+type Option func(*PyProject)
+
+// WithExtras adds optional dependency extras to install in the bundle.
+func WithExtras(extras ...string) Option {
+	return func(p *PyProject) {
+		p.Tool.PyBundler.Extras = append(p.Tool.PyBundler.Extras, extras...)
+	}
+}
+
+// WithAllExtras installs every optional dependency extra in the bundle.
+func WithAllExtras() Option {
+	return func(p *PyProject) {
+		p.Tool.PyBundler.AllExtras = true
+	}
+}
+
+// WithGroups adds dependency groups to install in the bundle.
+func WithGroups(groups ...string) Option {
+	return func(p *PyProject) {
+		p.Tool.PyBundler.Groups = append(p.Tool.PyBundler.Groups, groups...)
+	}
+}
+
+// WithCommandSeparator sets the separator used to split entry point names
+// into nested commands. An empty separator disables nesting.
+func WithCommandSeparator(separator string) Option {
+	return func(p *PyProject) {
+		p.Tool.PyBundler.CommandSeparator = &separator
+	}
 }
 
-func New(path string, output string, overwrite bool) (*BundleOptions, error) {
+func New(path string, output string, overwrite bool, opts ...Option) (*BundleOptions, error) {
 	if strings.TrimSpace(path) == "" {
 		path = "."
 	}
-	if strings.TrimSpace(output) == "." {
-		output = ""
+
+	bo := &BundleOptions{Path: path, Binary: "main"}
+	var err error
+	if info, statErr := os.Stat(path); statErr == nil && !info.IsDir() && filepath.Ext(path) == ".py" {
+		bo.Script, bo.Path = path, filepath.Dir(path)
+		bo.PyProject, err = NewScriptProject(bo.Script)
+		if err != nil {
+			return nil, err
+		}
+		bo.Binary = bo.PyProject.Project.Name
+		return bo.init(output, overwrite, opts)
+	}
+
+	bo.PyProject, err = NewPyProject(path)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding pyproject.toml: %v", err)
+	}
+	if !bo.PyProject.HasStaticMetadata() {
+		err = bo.readWheelMetadata(opts)
+		if err != nil {
+			os.RemoveAll(bo.WheelDir)
+			return nil, err
+		}
+	}
+	b, err := bo.init(output, overwrite, opts)
+	if err != nil {
+		os.RemoveAll(bo.WheelDir)
+	}
+	return b, err
+}
+
+// readWheelMetadata builds the wheel of the project into a temporary
+// directory and sets the dynamic metadata of the project from it. Projects
+// without a [project] table cannot be exported with `uv export`, so they
+// are bundled as their built wheel, like NewFromWheel does.
+func (bo *BundleOptions) readWheelMetadata(opts []Option) error {
+	index, err := indexOptions(*bo.PyProject, bo.Path, opts)
+	if err != nil {
+		return err
+	}
+	env, err := index.Environ()
+	if err != nil {
+		return err
+	}
+	slog.Info("Reading project metadata from the built wheel")
+	bo.WheelDir, err = os.MkdirTemp("", "pybundler-wheel-")
+	if err != nil {
+		return fmt.Errorf("creating wheel directory: %v", err)
+	}
+	bo.Wheel, err = BuildWheel(bo.Path, bo.WheelDir, env, false)
+	if err != nil {
+		return err
+	}
+	metadata, err := ReadWheelMetadata(bo.Wheel)
+	if err != nil {
+		return err
+	}
+	if bo.PyProject.Project.Name == "" {
+		wheel, err := filepath.Abs(bo.Wheel)
+		if err != nil {
+			return fmt.Errorf("getting absolute path for wheel: %v", err)
+		}
+		bo.Package = fmt.Sprintf("%s @ %s", metadata.Name, fileURL(wheel))
+	}
+	bo.PyProject.SetWheelMetadata(metadata)
+	return nil
+}
+
+// NewFromWheel bundles the entry points of an existing wheel. The
+// dependencies of the wheel are resolved with `uv pip compile` against the
+// configured indexes.
+func NewFromWheel(wheel string, output string, overwrite bool, opts ...Option) (*BundleOptions, error) {
+	wheel, err := filepath.Abs(wheel)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path for wheel: %v", err)
+	}
+	metadata, err := ReadWheelMetadata(wheel)
+	if err != nil {
+		return nil, err
+	}
+	bo := &BundleOptions{
+		Path:      ".",
+		Binary:    "main",
+		PyProject: &PyProject{Project: *metadata},
+		Package:   fmt.Sprintf("%s @ %s", metadata.Name, fileURL(wheel)),
+		Wheel:     wheel,
+	}
+	return bo.init(output, overwrite, opts)
+}
+
+// fileURL returns the file URL of the absolute path p.
+func fileURL(p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// NewFromPackage bundles the entry points of a published package given as
+// a requirement like `name==version`. The package is installed from the
+// indexes set with WithIndex, or the default index, to read its metadata.
+func NewFromPackage(requirement string, output string, overwrite bool, opts ...Option) (*BundleOptions, error) {
+	index, err := indexOptions(PyProject{}, ".", opts)
+	if err != nil {
+		return nil, err
+	}
+	env, err := index.Environ()
+	if err != nil {
+		return nil, err
+	}
+	target, err := os.MkdirTemp("", "pybundler-package-")
+	if err != nil {
+		return nil, fmt.Errorf("creating package directory: %v", err)
+	}
+	defer os.RemoveAll(target)
+
+	_, err = RunCmdWith(".", false, CmdOptions{Env: env}, "uv", "pip", "install", "--no-deps", "--target", target, requirement)
+	if err != nil {
+		return nil, fmt.Errorf("installing %s: %v", requirement, err)
+	}
+	metadata, err := ReadInstalledMetadata(target)
+	if err != nil {
+		return nil, err
+	}
+	bo := &BundleOptions{
+		Path:      ".",
+		Binary:    "main",
+		PyProject: &PyProject{Project: *metadata},
+		Package:   requirement,
+	}
+	return bo.init(output, overwrite, opts)
+}
+
+// NewFromWorkspace bundles the workspace members named members of the uv
+// workspace that contains path. A single member is bundled like a project
+// at its directory. The entry points of several members are merged into one
+// command tree; the bundle is then named after the workspace root project
+// or else the first member.
+func NewFromWorkspace(path string, members []string, output string, overwrite bool, opts ...Option) (*BundleOptions, error) {
+	if strings.TrimSpace(path) == "" {
+		path = "."
+	}
+	ws, err := FindWorkspace(path)
+	if err != nil {
+		return nil, err
+	}
+	if ws == nil {
+		return nil, fmt.Errorf("%s is not part of a uv workspace", path)
+	}
+	dirs := make([]string, 0)
+	for _, name := range members {
+		dir, ok := ws.Member(name)
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not a member of the workspace at %s", name, ws.Root)
+		}
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no workspace members selected")
+	}
+	if len(dirs) == 1 {
+		return New(dirs[0], output, overwrite, opts...)
+	}
+
+	bo := &BundleOptions{Path: ws.Root, Binary: "main"}
+	projects := make([]*PyProject, 0)
+	for _, dir := range dirs {
+		pyproject, err := NewPyProject(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding pyproject.toml: %v", err)
+		}
+		if !pyproject.HasStaticMetadata() {
+			index, err := indexOptions(*pyproject, dir, opts)
+			if err != nil {
+				return nil, err
+			}
+			env, err := index.Environ()
+			if err != nil {
+				return nil, err
+			}
+			wheelDir, err := os.MkdirTemp("", "pybundler-wheel-")
+			if err != nil {
+				return nil, fmt.Errorf("creating wheel directory: %v", err)
+			}
+			defer os.RemoveAll(wheelDir)
+			wheel, err := BuildWheel(dir, wheelDir, env, false)
+			if err != nil {
+				return nil, err
+			}
+			metadata, err := ReadWheelMetadata(wheel)
+			if err != nil {
+				return nil, err
+			}
+			pyproject.SetWheelMetadata(metadata)
+		}
+		// The merged project is bundled from the workspace root, so the
+		// paths of each member are resolved against its own directory.
+		pb := &pyproject.Tool.PyBundler
+		pb.IndexSection, err = pb.IndexSection.Resolve(dir)
+		if err != nil {
+			return nil, err
+		}
+		if readme := &pyproject.Project.Readme; readme.File != "" && !filepath.IsAbs(readme.File) {
+			readme.File = filepath.Join(dir, readme.File)
+		}
+		projects = append(projects, pyproject)
+		bo.Members = append(bo.Members, WorkspaceMember{Dir: dir, PyProject: pyproject})
 	}
 
-	pyproject, err := NewPyProject(path)
+	root, err := NewPyProject(ws.Root)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding pyproject.toml: %v", err)
 	}
+	name := projects[0].Project.Name
+	if root.Project.Name != "" {
+		name = root.Project.Name
+	}
+	// The configuration of the workspace root applies to every member,
+	// unless the root is bundled as a member itself.
+	base := PyBundlerSection{}
+	if !slices.Contains(dirs, ws.Root) {
+		base = root.Tool.PyBundler
+	}
+	bo.PyProject, err = MergeProjects(name, base, projects)
+	if err != nil {
+		return nil, err
+	}
+	return bo.init(output, overwrite, opts)
+}
+
+// init collects the commands of the project and prepares the output
+// directory.
+func (bo *BundleOptions) init(output string, overwrite bool, opts []Option) (*BundleOptions, error) {
+	if strings.TrimSpace(output) == "." {
+		output = ""
+	}
+	for _, opt := range opts {
+		opt(bo.PyProject)
+	}
+	pyproject := bo.PyProject
+	index, err := pyproject.Tool.PyBundler.IndexSection.Resolve(bo.Path)
+	if err != nil {
+		return nil, err
+	}
+	bo.Env, err = index.Environ()
+	if err != nil {
+		return nil, err
+	}
+	pyproject.Tool.PyBundler.IndexSection = index
 
 	scripts, err := NewCommandCollection(*pyproject)
 	if err != nil {
 		return nil, fmt.Errorf("error collecting scripts: %v", err)
 	}
+	for _, extra := range scripts.Extras() {
+		if !pyproject.HasExtra(extra) {
+			return nil, fmt.Errorf("entry points require extra '%s' which is not declared in [project.optional-dependencies]", extra)
+		}
+	}
+	bo.Commands = scripts
+	err = bo.checkDependencySelection()
+	if err != nil {
+		return nil, err
+	}
+	packaging, err := bo.Packaging()
+	if err != nil {
+		return nil, err
+	}
+	// The requires-python of a project is checked by uv when its wheel is
+	// installed, while the wheel of a script does not carry it.
+	if bo.Script != "" {
+		err = CheckRequiresPython(pyproject.Project.RequiresPython, packaging.PythonVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if strings.TrimSpace(output) == "" {
 		output = filepath.Join(DEFAULT_BUNDLE_DIR, pyproject.Project.Name)
@@ -48,75 +381,415 @@ func New(path string, output string, overwrite bool) (*BundleOptions, error) {
 			return nil, fmt.Errorf("getting absolute path for output directory: %v", err)
 		}
 	}
-
-	bundle := &BundleOptions{
-		Path:      path,
-		Output:    output,
-		PyProject: pyproject,
-		Commands:  scripts,
-	}
+	bo.Output = output
 
 	err = os.MkdirAll(output, os.ModePerm)
 	if err != nil {
 		return nil, fmt.Errorf("creating output directory: %v", err)
 	}
 
-	if _, err := os.Stat(bundle.Output); err == nil {
-		isEmpty, err := IsEmpty(bundle.Output)
+	if _, err := os.Stat(bo.Output); err == nil {
+		isEmpty, err := IsEmpty(bo.Output)
 		cobra.CheckErr(err)
 		if !isEmpty && !overwrite {
-			fp := filepath.Join(bundle.Output, "main.go")
+			fp := filepath.Join(bo.Output, "main.go")
 			slog.Info(fmt.Sprintf("File %s already exists. Use --overwrite to overwrite.", fp))
-			return nil, fmt.Errorf("output directory %s already exists", bundle.Output)
+			return nil, fmt.Errorf("output directory %s already exists", bo.Output)
 		}
-		err = os.RemoveAll(bundle.Output)
+		err = os.RemoveAll(bo.Output)
 		cobra.CheckErr(err)
-		err = os.MkdirAll(bundle.Output, os.ModePerm)
+		err = os.MkdirAll(bo.Output, os.ModePerm)
 		cobra.CheckErr(err)
 	}
 
-	slog.Info("Creating bundle:", "source", bundle.Path, "target", bundle.Output)
-	return bundle, nil
+	slog.Info("Creating bundle:", "source", bo.source(), "target", bo.Output)
+	return bo, nil
+}
+
+// checkDependencySelection checks that the configured extras and
+// dependency groups are declared by the bundled project.
+func (bo *BundleOptions) checkDependencySelection() error {
+	pb := bo.PyProject.Tool.PyBundler
+	if bo.Script != "" && (len(pb.Extras) > 0 || pb.AllExtras || len(pb.Groups) > 0) {
+		return fmt.Errorf("extras and dependency groups cannot be selected for scripts")
+	}
+	if bo.Package != "" && len(pb.Groups) > 0 {
+		return fmt.Errorf("dependency groups cannot be selected for wheels, published packages and projects without a [project] table")
+	}
+	for _, extra := range pb.Extras {
+		if !bo.PyProject.HasExtra(extra) {
+			return fmt.Errorf("extra '%s' is not declared in [project.optional-dependencies]", extra)
+		}
+	}
+	for _, group := range pb.Groups {
+		found := bo.PyProject.HasGroup(group)
+		for _, m := range bo.Members {
+			found = found || m.PyProject.HasGroup(group)
+		}
+		if !found {
+			return fmt.Errorf("dependency group '%s' is not declared in [dependency-groups]", group)
+		}
+	}
+	return nil
+}
+
+// Extras returns the sorted extras installed in the bundle: the configured
+// extras, every extra with all-extras, and the extras required by the
+// entry points.
+func (bo *BundleOptions) Extras() []string {
+	pb := bo.PyProject.Tool.PyBundler
+	extras := append([]string{}, pb.Extras...)
+	if pb.AllExtras {
+		extras = slices.AppendSeq(extras, maps.Keys(bo.PyProject.Project.OptionalDependencies))
+	}
+	extras = append(extras, bo.Commands.Extras()...)
+	slices.Sort(extras)
+	return slices.Compact(extras)
+}
+
+// source describes what is bundled.
+func (bo *BundleOptions) source() string {
+	switch {
+	case bo.Script != "":
+		return bo.Script
+	case bo.Package != "":
+		return bo.Package
+	}
+	return bo.Path
+}
+
+// python returns the command that runs Python with the project and the
+// dependencies of its entry points installed.
+func (bo *BundleOptions) python() []string {
+	if bo.Package != "" {
+		python := []string{"uv", "run", "--no-project"}
+		for _, req := range bo.packageRequirements() {
+			python = append(python, "--with", req)
+		}
+		return append(python, "python")
+	}
+	if len(bo.Members) > 0 {
+		return []string{"uv", "run", "--no-dev", "--all-packages", "--all-extras", "python"}
+	}
+	python := []string{"uv", "run"}
+	if !slices.ContainsFunc(bo.PyProject.Tool.PyBundler.Groups, func(group string) bool { return NormalizeName(group) == "dev" }) {
+		python = append(python, "--no-dev")
+	}
+	for _, extra := range bo.Extras() {
+		python = append(python, "--extra", extra)
+	}
+	for _, group := range bo.PyProject.Tool.PyBundler.Groups {
+		python = append(python, "--group", group)
+	}
+	return append(python, "python")
+}
+
+// run runs a command for the bundle in dir, with the environment of the
+// bundle.
+func (bo *BundleOptions) run(dir string, verbose bool, args ...string) ([]byte, error) {
+	return RunCmdWith(dir, verbose, CmdOptions{Env: bo.Env}, args...)
+}
+
+// packageRequirements returns the requirements that install the bundled
+// package with the extras its entry points need.
+func (bo *BundleOptions) packageRequirements() []string {
+	reqs := []string{bo.Package}
+	if extras := bo.Extras(); len(extras) > 0 {
+		reqs = append(reqs, fmt.Sprintf("%s[%s]", bo.PyProject.Project.Name, strings.Join(extras, ",")))
+	}
+	return reqs
+}
+
+// exportRequirements returns the pinned dependencies of the project, script
+// or package in requirements.txt format, and the local projects and wheels
+// they depend on, like workspace members and path dependencies.
+func (bo *BundleOptions) exportRequirements(verbose bool) ([]byte, []string, error) {
+	switch {
+	case bo.Script != "":
+		out, err := bo.run(bo.Path, verbose, "uv", "export", "--script", filepath.Base(bo.Script))
+		return out, nil, err
+	case bo.Package != "":
+		input := filepath.Join(bo.Output, "requirements.in")
+		err := os.WriteFile(input, []byte(strings.Join(bo.packageRequirements(), "\n")+"\n"), 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("writing requirements.in: %v", err)
+		}
+		out, err := bo.run(bo.Path, verbose, "uv", "pip", "compile", input, "--universal", "--generate-hashes", "--no-header", "--no-annotate")
+		return out, localRequirements(out, bo.Path), err
+	case len(bo.Members) > 0:
+		var out []byte
+		local := make([]string, 0)
+		for _, m := range bo.Members {
+			local = append(local, m.Dir)
+		}
+		for _, m := range bo.Members {
+			extras := slices.DeleteFunc(bo.Extras(), func(extra string) bool {
+				return !m.PyProject.HasExtra(extra)
+			})
+			groups := slices.DeleteFunc(slices.Clone(bo.PyProject.Tool.PyBundler.Groups), func(group string) bool {
+				return !m.PyProject.HasGroup(group)
+			})
+			reqs, err := exportProject(m.Dir, extras, groups, bo.Env, verbose)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = append(out, reqs...)
+			local = append(local, localRequirements(reqs, m.Dir)...)
+		}
+		return out, local, nil
+	}
+	out, err := exportProject(bo.Path, bo.Extras(), bo.PyProject.Tool.PyBundler.Groups, bo.Env, verbose)
+	return out, localRequirements(out, bo.Path), err
+}
+
+// exportProject exports the dependencies of the project at dir with the
+// given extras and dependency groups, with env added to the environment of
+// uv.
+func exportProject(dir string, extras []string, groups []string, env []string, verbose bool) ([]byte, error) {
+	args := []string{"uv", "export", "--no-emit-project"}
+	if !slices.ContainsFunc(groups, func(group string) bool { return NormalizeName(group) == "dev" }) {
+		args = append(args, "--no-dev")
+	}
+	for _, extra := range extras {
+		args = append(args, "--extra", extra)
+	}
+	for _, group := range groups {
+		args = append(args, "--group", group)
+	}
+	return RunCmdWith(dir, verbose, CmdOptions{Env: env}, args...)
+}
+
+// localRequirements returns the local paths required by the output of
+// `uv export` at dir. Paths are relative to dir or, inside a workspace, to
+// the workspace root.
+func localRequirements(pkgReqs []byte, dir string) []string {
+	bases := []string{dir}
+	if ws, err := FindWorkspace(dir); err == nil && ws != nil {
+		bases = append(bases, ws.Root)
+	}
+	local := make([]string, 0)
+	for _, line := range RequirementLines(pkgReqs) {
+		for _, base := range bases {
+			p, ok := LocalRequirement(line, base)
+			if !ok {
+				break
+			}
+			if _, err := os.Stat(p); err == nil || base == bases[len(bases)-1] {
+				local = append(local, p)
+				break
+			}
+		}
+	}
+	return local
 }
 
 func (bo *BundleOptions) Run(verbose bool) error {
-	_, err := RunCmd(bo.Output, verbose, "go", "mod", "init", bo.PyProject.Project.Name)
-	cobra.CheckErr(err)
-	err = RenderProject(bo)
+	offline := bo.Cache != nil && bo.Cache.Offline
+	if offline {
+		err := bo.checkOffline(verbose)
+		cobra.CheckErr(err)
+	}
+	if bo.Script == "" {
+		err := bo.Commands.Introspect(bo.Path, bo.python(), bo.Env, verbose)
+		cobra.CheckErr(err)
+	}
+	err := RenderProject(bo)
 	cobra.CheckErr(err)
-	_, err = RunCmd(bo.Output, verbose, "go", "mod", "tidy")
+	module := bo.GoModule()
+	python, err := PythonVersion(module.GoEmbedPython)
 	cobra.CheckErr(err)
+	slog.Info("Using Go dependencies", "go-embed-python", module.GoEmbedPython, "python", python, "cobra", module.Cobra)
+	// go.mod and go.sum are complete for the pinned versions, so that the
+	// checksums of the release are used rather than whatever go resolves.
+	if !module.Pinned() {
+		_, err = bo.run(bo.Output, verbose, "go", "mod", "tidy")
+		cobra.CheckErr(err)
+	}
 
-	_, err = RunCmd(bo.Path, verbose, "uv", "build", "--wheel", "-o", bo.Output)
+	err = bo.buildWheel(verbose)
+	cobra.CheckErr(err)
+	pkgReqs, local, err := bo.exportRequirements(verbose)
 	cobra.CheckErr(err)
-	pkgReqs, err := RunCmd(bo.Path, verbose, "uv", "export", "--no-emit-project", "--no-dev", "--no-hashes")
+	wheels, err := bo.buildLocalWheels(local, verbose)
 	cobra.CheckErr(err)
-	requirements, err := bo.parseRequirements(pkgReqs)
+	var built []BuiltWheel
+	if !offline {
+		built, err = bo.checkWheels(pkgReqs, verbose)
+		cobra.CheckErr(err)
+	}
+	requirements, err := bo.parseRequirements(pkgReqs, wheels, built)
+	cobra.CheckErr(err)
+	err = bo.Commands.CheckServers(RequirementNames(pkgReqs))
 	cobra.CheckErr(err)
 	err = os.WriteFile(filepath.Join(bo.Output, "requirements.txt"), requirements, 0644)
 	cobra.CheckErr(err)
-	_, err = RunCmd(bo.Output, verbose, "go", "generate", "./...")
+	err = bo.Manifest().Write(bo.Output)
+	cobra.CheckErr(err)
+	_, err = bo.run(bo.Output, verbose, "go", "generate", "./...")
+	var cmdErr *CmdError
+	if errors.As(err, &cmdErr) {
+		if pkgs := HashMismatches(cmdErr.Stderr); len(pkgs) > 0 {
+			err = fmt.Errorf("downloaded artifacts do not match the locked hashes of %s", strings.Join(pkgs, ", "))
+		}
+	}
 	cobra.CheckErr(err)
 
-	_, err = RunCmd(bo.Output, verbose, "go", "fmt", "./...")
+	_, err = bo.run(bo.Output, verbose, "go", "fmt", "./...")
 	cobra.CheckErr(err)
-	_, err = RunCmd(bo.Output, verbose, "go", "mod", "tidy")
+	platforms, err := bo.Platforms()
 	cobra.CheckErr(err)
-	_, err = RunCmd(bo.Output, verbose, "go", "build", "-o", "main")
+	host, ok := HostPlatform()
+	if !ok {
+		slog.Warn("Not building the binary, go-embed-python does not support the host platform; set GOOS and GOARCH to build it in the output directory for a selected platform", "host", host.String(), "output", bo.Output)
+		return nil
+	}
+	if !slices.ContainsFunc(platforms, func(p Platform) bool { return p.String() == host.String() }) {
+		slog.Warn("Not building the binary, the host platform is not selected; set GOOS and GOARCH to build it in the output directory", "host", host.String(), "output", bo.Output)
+		return nil
+	}
+	_, err = bo.run(bo.Output, verbose, "go", "build", "-mod=readonly", "-o", bo.Binary)
 	cobra.CheckErr(err)
 	slog.Info("Bundle created successfully.")
 	return nil
 }
 
-func (bo *BundleOptions) parseRequirements(pkgReqs []byte) ([]byte, error) {
+// buildWheel builds the wheel of the project into the output directory, or
+// moves it there when it was already built to read the project metadata.
+// Scripts are packaged as a wheel of their own, bundled wheels are copied
+// and published packages are installed from their index instead.
+func (bo *BundleOptions) buildWheel(verbose bool) error {
+	if len(bo.Members) > 0 {
+		return nil
+	}
+	if bo.Package != "" && bo.Wheel == "" {
+		return nil
+	}
+	if bo.Script != "" {
+		wheel, err := WriteScriptWheel(bo.Script, bo.PyProject.Project.Name, bo.Output)
+		if err != nil {
+			return err
+		}
+		bo.Wheel = wheel
+		return nil
+	}
+	if bo.Wheel == "" {
+		wheel, err := BuildWheel(bo.Path, bo.Output, bo.Env, verbose)
+		if err != nil {
+			return err
+		}
+		bo.Wheel = wheel
+		return nil
+	}
+	wheel := filepath.Join(bo.Output, filepath.Base(bo.Wheel))
+	err := copyFile(bo.Wheel, wheel)
+	if err != nil {
+		return fmt.Errorf("copying wheel: %v", err)
+	}
+	if bo.WheelDir != "" {
+		err = os.RemoveAll(bo.WheelDir)
+		if err != nil {
+			return fmt.Errorf("removing wheel directory: %v", err)
+		}
+		bo.WheelDir = ""
+	}
+	bo.Wheel = wheel
+	if bo.Package != "" {
+		abs, err := filepath.Abs(wheel)
+		if err != nil {
+			return fmt.Errorf("getting absolute path for wheel: %v", err)
+		}
+		bo.Package = fmt.Sprintf("%s @ %s", bo.PyProject.Project.Name, fileURL(abs))
+	}
+	return nil
+}
+
+// buildLocalWheels builds the wheels of the local projects in local into
+// the output directory and returns the file names of all wheels to install,
+// starting with the wheel of the project. Local wheels are copied.
+func (bo *BundleOptions) buildLocalWheels(local []string, verbose bool) ([]string, error) {
+	wheels := make([]string, 0)
+	if bo.Wheel != "" {
+		wheels = append(wheels, filepath.Base(bo.Wheel))
+	}
+	seen := map[string]bool{}
+	for _, p := range local {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("local requirement %s: %v", p, err)
+		}
+		wheel := filepath.Join(bo.Output, filepath.Base(p))
+		switch {
+		case info.IsDir():
+			slog.Info("Building wheel of local dependency", "path", p)
+			wheel, err = BuildWheel(p, bo.Output, bo.Env, verbose)
+		case filepath.Ext(p) == ".whl":
+			if filepath.Base(p) != filepath.Base(bo.Wheel) {
+				err = copyFile(p, wheel)
+			}
+		default:
+			err = fmt.Errorf("only projects and wheels are supported")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("local requirement %s: %v", p, err)
+		}
+		if !slices.Contains(wheels, filepath.Base(wheel)) {
+			wheels = append(wheels, filepath.Base(wheel))
+		}
+	}
+	return wheels, nil
+}
+
+// parseRequirements returns the requirements.txt installed into the bundle
+// for every platform. Every requirement is pinned with a hash, local wheels
+// and wheels built from sdists with the hash of the built file, and pip is
+// made to verify them.
+func (bo *BundleOptions) parseRequirements(pkgReqs []byte, wheels []string, built []BuiltWheel) ([]byte, error) {
 	slog.Info("Getting module requirements")
-	whl := fmt.Sprintf("%s-%s-py3-none-any.whl", strings.ReplaceAll(bo.PyProject.Project.Name, "-", "_"), bo.PyProject.Project.Version)
-	reqLines := bytes.Split(pkgReqs, []byte("\n"))
-	reqs := [][]byte{[]byte(whl)}
-	for _, line := range reqLines {
-		if !bytes.HasPrefix(line, []byte("#")) && bytes.Contains(line, []byte("==")) {
-			reqs = append(reqs, line)
+	reqs := append([]string{REQUIRE_HASHES}, bo.PyProject.Tool.PyBundler.Requirements()...)
+	if bo.Cache != nil && bo.Cache.Offline {
+		reqs = append([]string{REQUIRE_HASHES}, bo.Cache.requirements()...)
+	}
+	for _, wheel := range wheels {
+		hash, err := FileHash(filepath.Join(bo.Output, wheel))
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, wheel+" "+hash)
+	}
+	for _, line := range RequirementLines(pkgReqs) {
+		if strings.HasPrefix(line, "#") || !strings.Contains(line, "==") || slices.Contains(reqs, line) {
+			continue
+		}
+		if !HasHashes(line) {
+			name, _, _ := strings.Cut(line, " ")
+			return nil, fmt.Errorf("requirement %s is not pinned with a hash", name)
+		}
+		if req, ok := ParseRequirement(line); ok {
+			if i := slices.IndexFunc(built, func(w BuiltWheel) bool { return w.Requirement.Name == req.Name }); i >= 0 {
+				reqs = append(reqs, built[i].Lines()...)
+				continue
+			}
+		}
+		reqs = append(reqs, line)
+	}
+	return []byte(strings.Join(reqs, "\n") + "\n"), nil
+}
+
+// RequirementNames returns the normalized names of the pinned packages in
+// the output of `uv export`.
+func RequirementNames(pkgReqs []byte) map[string]bool {
+	names := map[string]bool{}
+	for _, line := range RequirementLines(pkgReqs) {
+		name, _, ok := strings.Cut(line, "==")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
 		}
+		name, _, _ = strings.Cut(name, "[")
+		names[NormalizeName(strings.TrimSpace(name))] = true
 	}
-	return bytes.Join(reqs, []byte("\n")), nil
+	return names
 }
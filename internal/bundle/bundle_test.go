@@ -58,3 +58,61 @@ func TestRun(t *testing.T) {
 		}
 	}
 }
+
+// TestRunSetupCfg bundles a project configured only with setup.cfg, whose
+// dependencies are resolved from its built wheel.
+func TestRunSetupCfg(t *testing.T) {
+	if _, err := exec.LookPath("uv"); err != nil {
+		t.Skip("uv not available")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"setup.cfg": `[metadata]
+name = greeter
+version = 0.1.0
+description = Greets from setup.cfg
+
+[options]
+py_modules = greeter
+install_requires =
+    colorama
+
+[options.entry_points]
+console_scripts =
+    greet = greeter:main
+`,
+		"greeter.py": `def main():
+    import colorama
+    print("Hello from greeter!")
+`,
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(t.TempDir(), "out")
+	b, err := bundle.New(dir, output, false)
+	if err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	if b.PyProject.Project.Name != "greeter" || b.Commands.Scripts.Child("greet") == nil {
+		t.Fatal("expected metadata and commands from the built wheel")
+	}
+	err = b.Run(false)
+	if err != nil {
+		t.Fatalf("Failed to run bundle: %v", err)
+	}
+	if b.WheelDir != "" {
+		t.Fatalf("expected the wheel directory to be removed, got %s", b.WheelDir)
+	}
+	out, err := exec.Command(filepath.Join(output, b.Binary), "greet").Output()
+	if err != nil {
+		t.Fatalf("Failed to run command: %v", err)
+	}
+	if string(out) != "Hello from greeter!\n" {
+		t.Fatalf("Unexpected output: %q", out)
+	}
+}
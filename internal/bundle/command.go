@@ -1,73 +1,294 @@
 package bundle
 
 import (
+	"cmp"
 	"fmt"
 	"log/slog"
+	"path"
+	"slices"
 	"strings"
+	"unicode"
 )
 
+// DEFAULT_COMMAND_SEPARATOR splits entry point names into nested commands,
+// so that `db.migrate` becomes `db migrate`. Whitespace always separates
+// commands.
+const DEFAULT_COMMAND_SEPARATOR = "."
+
+// Command is a node in the generated cobra command tree. The root command is
+// rendered into the cmd package and every other command into its own
+// package below internal/. A command is runnable when it has an entry point
+// and a group when it has sub commands; it may be both.
 type Command struct {
-	Origin     string
-	AppName    string
-	Module     string
-	Import     string
-	CmdVarName string
-	CmdUse     string
-	Cmd        string
-	Commands   []*Command
-}
-
-func NewCommand(appName, name, value, origin string, commands ...*Command) (*Command, error) {
-	slog.Debug("Creating command input", "appName", appName, "name", name, "value", value, "origin", origin)
-
-	parts := strings.SplitN(value, ":", 2)
-	if len(parts) != 2 {
-		slog.Error("Invalid script format", "appName", appName, "name", name, "value", value, "origin", origin)
-		return nil, fmt.Errorf("invalid script format: %s", value)
-	}
-	import_module := strings.TrimSpace(parts[0])
-	method := strings.TrimSpace(parts[1])
-	method = strings.TrimPrefix(method, import_module+".")
-	cmd := fmt.Sprintf("import %s; %s.%s()", import_module, import_module, method)
-	parts = strings.Split(import_module, ".")
-	module := parts[len(parts)-1]
-	cmdUse := strings.TrimSpace(name)
-	cmdUse = strings.ReplaceAll(cmdUse, " ", "-")
-	cmdUse = strings.ReplaceAll(cmdUse, "_", "-")
-	cmdVarName := strings.ReplaceAll(cmdUse, "-", "_")
-	m := module + RandomString(5)
-
-	slog.Debug("Creating command output",
-		"AppName", appName,
-		"Origin", origin,
-		"Module", module+RandomString(5),
-		"CmdVarName", ToPascalCase(cmdVarName),
-		"CmdUse", cmdUse,
-		"Cmd", cmd,
-		"Import", fmt.Sprintf("%s/cmd/%s", appName, cmdVarName),
-		"Commands", commands,
-	)
+	AppName  string
+	Name     string
+	Origin   string
+	Entry    *EntryPoint
+	PyArgs   []string
+	Parent   *Command
+	Children []*Command
 
-	return &Command{
-		AppName:    appName,
-		Module:     m,
-		Import:     m,
-		CmdVarName: ToPascalCase(cmdVarName),
-		CmdUse:     cmdUse,
-		Cmd:        cmd,
-		Commands:   commands,
-	}, nil
+	Short   string
+	Long    string
+	Aliases []string
+	Hidden  bool
+	Group   string
+	Order   *int
+
+	// Snapshot is set when the help of the command is answered from a
+	// snapshot of the Python command line interface taken at build time.
+	// Usage and Flags are then taken from the snapshot and Prefix holds the
+	// arguments that select the command in Python.
+	Snapshot bool
+	Usage    string
+	Flags    []Flag
+	Prefix   []string
+
+	// Introspect is set when the entry point may be called while bundling
+	// to capture its argparse parser.
+	Introspect bool
+
+	// Prog is the program name the entry point sees in sys.argv[0] and
+	// Completion the instruction that asks it for shell completions.
+	Prog       string
+	Completion string
+
+	// Server is the kind of application, asgi or wsgi, served by the
+	// command.
+	Server string
+
+	// Version, Footer and Optimize are only set on the root command.
+	// Optimize is the optimization level the bytecode was compiled with.
+	Version  string
+	Footer   string
+	Optimize int
+
+	// Package and VarName are the Go package and variable the command is
+	// rendered as. They are assigned by CommandCollection.ResolveNames.
+	Package string
+	VarName string
 }
 
-func NewRootCommand(appName, module string, commands ...*Command) (*Command, error) {
+// NewRootCommand returns the root of a command tree for the Go module
+// appName.
+func NewRootCommand(appName string, commands ...*Command) *Command {
 	root := &Command{
-		AppName:    appName,
-		Module:     strings.ReplaceAll(module, "-", "_"),
-		Import:     strings.ReplaceAll(module, "-", "_"),
-		CmdVarName: fmt.Sprintf("%sCmd", ToPascalCase(module)),
-		CmdUse:     module,
-		Cmd:        "",
-		Commands:   commands,
-	}
-	return root, nil
+		AppName: appName,
+		Name:    appName,
+		Package: "cmd",
+		VarName: "rootCmd",
+	}
+	for _, c := range commands {
+		root.AddCommand(c)
+	}
+	return root
+}
+
+// NewGroup returns a command without an entry point.
+func NewGroup(appName, name, origin string) *Command {
+	return &Command{
+		AppName: appName,
+		Name:    name,
+		Origin:  origin,
+	}
+}
+
+func (c *Command) IsRoot() bool {
+	return c.Parent == nil && c.Package == "cmd"
+}
+
+func (c *Command) IsGroup() bool {
+	return len(c.Children) > 0
+}
+
+func (c *Command) IsRunnable() bool {
+	return len(c.PyArgs) > 0
+}
+
+// CompleteVars returns the environment variables through which Click and
+// Typer applications named Prog are asked for shell completions. Click
+// replaces dots in the program name while Typer keeps them.
+func (c *Command) CompleteVars() []string {
+	name := strings.ReplaceAll(c.Prog, "-", "_")
+	vars := []string{strings.ToUpper("_" + name + "_COMPLETE")}
+	if dotless := strings.ReplaceAll(name, ".", "_"); dotless != name {
+		vars = append(vars, strings.ToUpper("_"+dotless+"_COMPLETE"))
+	}
+	return vars
+}
+
+// UseLine returns the one line usage message of c.
+func (c *Command) UseLine() string {
+	if c.Usage == "" {
+		return c.Name
+	}
+	return c.Name + " " + c.Usage
+}
+
+func (c *Command) AddCommand(child *Command) {
+	child.Parent = c
+	c.Children = append(c.Children, child)
+}
+
+// Child returns the direct sub command with the given name.
+func (c *Command) Child(name string) *Command {
+	for _, child := range c.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Path returns the names of the commands leading from the top of the tree
+// to c.
+func (c *Command) Path() []string {
+	if c.Parent == nil {
+		return []string{}
+	}
+	return append(c.Parent.Path(), c.Name)
+}
+
+// Dir returns the directory of the package c is rendered into, relative to
+// the root of the generated project.
+func (c *Command) Dir() string {
+	if c.IsRoot() {
+		return c.Package
+	}
+	if c.Parent == nil || c.Parent.IsRoot() {
+		return path.Join("internal", c.Package)
+	}
+	return path.Join(c.Parent.Dir(), c.Package)
+}
+
+// Lookup returns the command at the given path below c.
+func (c *Command) Lookup(names []string) *Command {
+	node := c
+	for _, name := range names {
+		node = node.Child(name)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Groups returns the distinct help groups of the sub commands of c in the
+// order they first appear.
+func (c *Command) Groups() []string {
+	groups := make([]string, 0)
+	for _, child := range c.Children {
+		if child.Group != "" && !slices.Contains(groups, child.Group) {
+			groups = append(groups, child.Group)
+		}
+	}
+	return groups
+}
+
+// Configure applies the metadata from [tool.pybundler.commands.<name>].
+func (c *Command) Configure(cfg CommandConfig) {
+	if cfg.Short != "" {
+		c.Short = cfg.Short
+	}
+	if cfg.Long != "" {
+		c.Long = cfg.Long
+	}
+	c.Aliases = append(c.Aliases, cfg.Aliases...)
+	c.Hidden = c.Hidden || cfg.Hidden
+	if cfg.Group != "" {
+		c.Group = cfg.Group
+	}
+	if cfg.Order != nil {
+		c.Order = cfg.Order
+	}
+	c.Introspect = c.Introspect || cfg.Introspect
+}
+
+// SortCommands orders the sub commands of c and all commands below it by
+// their configured order and then by name. Commands without an order come
+// last.
+func (c *Command) SortCommands() {
+	slices.SortStableFunc(c.Children, func(a, b *Command) int {
+		switch {
+		case a.Order != nil && b.Order != nil && *a.Order != *b.Order:
+			return cmp.Compare(*a.Order, *b.Order)
+		case a.Order != nil && b.Order == nil:
+			return -1
+		case a.Order == nil && b.Order != nil:
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, child := range c.Children {
+		child.SortCommands()
+	}
+}
+
+// Walk calls fn for c and every command below it.
+func (c *Command) Walk(fn func(*Command)) {
+	fn(c)
+	for _, child := range c.Children {
+		child.Walk(fn)
+	}
+}
+
+// Insert adds an entry point at the given path below c, creating the
+// intermediate groups as needed.
+func (c *Command) Insert(names []string, entry *EntryPoint, origin string) (*Command, error) {
+	slog.Debug("Inserting command", "parent", c.Name, "path", names, "entry", entry, "origin", origin)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s: empty command name", origin)
+	}
+	node := c
+	for _, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("%s: empty command name in '%s'", origin, strings.Join(names, " "))
+		}
+		child := node.Child(name)
+		if child == nil {
+			child = NewGroup(c.AppName, name, origin)
+			node.AddCommand(child)
+		}
+		node = child
+	}
+	if node.Entry != nil {
+		return nil, fmt.Errorf("command name '%s' is defined by both %s and %s", strings.Join(names, " "), node.Origin, origin)
+	}
+	node.Entry = entry
+	node.Origin = origin
+	return node, nil
+}
+
+// SplitCommandName splits an entry point name into the names of the nested
+// commands it defines.
+func SplitCommandName(name, separator string) []string {
+	names := make([]string, 0)
+	for _, field := range strings.FieldsFunc(name, unicode.IsSpace) {
+		parts := []string{field}
+		if separator != "" {
+			parts = strings.Split(field, separator)
+		}
+		for _, p := range parts {
+			names = append(names, strings.ReplaceAll(strings.TrimSpace(p), "_", "-"))
+		}
+	}
+	return names
+}
+
+// NewLauncher returns the Python source that invokes an entry point the same
+// way the console_scripts wrapper generated by pip does: the entry point is
+// imported, sys.argv[0] is set to the program name and the return value is
+// passed to sys.exit. Entry points returning a coroutine are run with
+// asyncio.run before exiting. The launcher imports its own modules under
+// private names, so entry points may be named like them.
+func NewLauncher(prog, module, attr string) string {
+	importName := strings.SplitN(attr, ".", 2)[0]
+	return strings.Join([]string{
+		"import inspect as _pyb_inspect, sys as _pyb_sys",
+		fmt.Sprintf("from %s import %s", module, importName),
+		fmt.Sprintf("_pyb_sys.argv[0] = %s", PyString(prog)),
+		fmt.Sprintf("_result = %s()", attr),
+		"_result = __import__('asyncio').run(_result) if _pyb_inspect.iscoroutine(_result) else _result",
+		"_pyb_sys.exit(_result)",
+	}, "; ")
 }
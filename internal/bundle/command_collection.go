@@ -1,70 +1,213 @@
 package bundle
 
-import "fmt"
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+)
 
+// CommandCollection holds the command trees for the scripts, gui scripts
+// and entry point groups of a project.
 type CommandCollection struct {
-	Scripts     []*Command
-	GuiScripts  []*Command
-	EntryPoints []*Command
+	AppName     string
+	Separator   string
+	Scripts     *Command
+	GuiScripts  *Command
+	EntryPoints *Command
+	Apps        *Command
 }
 
 func NewCommandCollection(pyproject PyProject) (*CommandCollection, error) {
-	project_name := pyproject.Project.Name
+	project_name, err := GoModulePath(pyproject.Project.Name)
+	if err != nil {
+		return nil, err
+	}
 	sc := CommandCollection{
-		Scripts:     make([]*Command, 0),
-		GuiScripts:  make([]*Command, 0),
-		EntryPoints: make([]*Command, 0),
+		AppName:     project_name,
+		Separator:   pyproject.CommandSeparator(),
+		Scripts:     NewGroup(project_name, "scripts", PyProjectKey("project", "scripts")),
+		GuiScripts:  NewGroup(project_name, "gui", PyProjectKey("project", "gui-scripts")),
+		EntryPoints: NewGroup(project_name, "entrypoint", PyProjectKey("project", "entry-points")),
+		Apps:        NewGroup(project_name, SERVE_COMMAND, PyProjectKey("tool", "pybundler", "apps")),
 	}
 
-	for group_name, group := range pyproject.Project.EntryPoints {
+	for _, group_name := range slices.Sorted(maps.Keys(pyproject.Project.EntryPoints)) {
+		// Scripts declared as entry points are merged with the scripts
+		// tables below.
 		if group_name == "console_scripts" || group_name == "gui_scripts" {
 			continue
 		}
-		entry_cmds := make([]*Command, 0)
-		for k, v := range group {
-			s, err := NewCommand(project_name, k, v, group_name)
+		group := pyproject.Project.EntryPoints[group_name]
+		if len(group) == 0 {
+			return nil, fmt.Errorf("no entry points found in group '%s'", group_name)
+		}
+		group_root := NewGroup(project_name, strings.ReplaceAll(strings.TrimSpace(group_name), "_", "-"), PyProjectKey("project", "entry-points", group_name))
+		if other := sc.EntryPoints.Child(group_root.Name); other != nil {
+			return nil, fmt.Errorf("entry point group name '%s' is defined by both %s and %s", group_root.Name, other.Origin, group_root.Origin)
+		}
+		sc.EntryPoints.AddCommand(group_root)
+		for _, k := range slices.Sorted(maps.Keys(group)) {
+			err := sc.add(group_root, k, group[k], PyProjectKey("project", "entry-points", group_name, k))
 			if err != nil {
 				return nil, fmt.Errorf("error creating entry point '%s': %v", k, err)
 			}
-			if s == nil {
-				return nil, fmt.Errorf("entry point '%s' is nil", k)
-			}
-			entry_cmds = append(entry_cmds, s)
-		}
-		if len(entry_cmds) == 0 {
-			return nil, fmt.Errorf("no entry points found in group '%s'", group_name)
 		}
-		group_root, err := NewRootCommand(project_name, group_name, entry_cmds...)
+	}
+
+	err = sc.addScripts(sc.Scripts, "script", pyproject.Project.Scripts, "scripts", pyproject.Project.EntryPoints["console_scripts"], "console_scripts")
+	if err != nil {
+		return nil, err
+	}
+	err = sc.addScripts(sc.GuiScripts, "gui script", pyproject.Project.GuiScripts, "gui-scripts", pyproject.Project.EntryPoints["gui_scripts"], "gui_scripts")
+	if err != nil {
+		return nil, err
+	}
+
+	err = sc.addApps(pyproject.Tool.PyBundler.Apps)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(pyproject.Tool.PyBundler.Commands)) {
+		err := sc.configure(name, pyproject.Tool.PyBundler.Commands[name])
 		if err != nil {
-			return nil, fmt.Errorf("error creating entry point group '%s': %v", group_name, err)
-		}
-		if group_root == nil {
-			return nil, fmt.Errorf("entry point group '%s' is nil", group_name)
+			return nil, err
 		}
-		sc.EntryPoints = append(sc.EntryPoints, group_root)
 	}
+	for _, group := range sc.Trees() {
+		group.SortCommands()
+	}
+
+	err = sc.ResolveNames()
+	if err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+func (sc *CommandCollection) add(parent *Command, name, value, origin string) error {
+	entry, err := ParseEntryPoint(value)
+	if err != nil {
+		return fmt.Errorf("invalid script format: %v", err)
+	}
+	c, err := parent.Insert(SplitCommandName(name, sc.Separator), entry, origin)
+	if err != nil {
+		return err
+	}
+	c.Prog = strings.TrimSpace(name)
+	c.PyArgs = entry.PythonArgs(c.Prog)
+	return nil
+}
 
-	for k, v := range pyproject.Project.Scripts {
-		s, err := NewCommand(project_name, k, v, "scripts")
+// addScripts adds the scripts from [project.<table>] and from the legacy
+// [project.entry-points.<group>] group below parent. Names defined in both
+// places are taken from [project.<table>].
+func (sc *CommandCollection) addScripts(parent *Command, kind string, scripts map[string]string, table string, legacy map[string]string, group string) error {
+	for _, k := range slices.Sorted(maps.Keys(scripts)) {
+		err := sc.add(parent, k, scripts[k], PyProjectKey("project", table, k))
 		if err != nil {
-			return nil, fmt.Errorf("error creating script '%s': %v", k, err)
+			return fmt.Errorf("error creating %s '%s': %v", kind, k, err)
 		}
-		if s == nil {
-			return nil, fmt.Errorf("script '%s' is nil", k)
+	}
+	for _, k := range slices.Sorted(maps.Keys(legacy)) {
+		origin := PyProjectKey("project", "entry-points", group, k)
+		if _, ok := scripts[k]; ok {
+			slog.Warn("Script is defined twice, ignoring the entry point", "script", k, "used", PyProjectKey("project", table, k), "ignored", origin)
+			continue
+		}
+		err := sc.add(parent, k, legacy[k], origin)
+		if err != nil {
+			return fmt.Errorf("error creating %s '%s': %v", kind, k, err)
 		}
-		sc.Scripts = append(sc.Scripts, s)
 	}
+	return nil
+}
 
-	for k, v := range pyproject.Project.GuiScripts {
-		s, err := NewCommand(project_name, k, v, "gui-scripts")
-		if err != nil {
-			return nil, fmt.Errorf("error creating gui script '%s': %v", k, err)
+// configure applies the metadata configured for name to the matching
+// commands. Names are matched against the command path below the scripts,
+// gui scripts and entry points groups.
+func (sc *CommandCollection) configure(name string, cfg CommandConfig) error {
+	names := SplitCommandName(name, sc.Separator)
+	found := false
+	for _, group := range []*Command{sc.Scripts, sc.GuiScripts, sc.EntryPoints} {
+		if c := group.Lookup(names); c != nil && len(names) > 0 {
+			c.Configure(cfg)
+			found = true
 		}
-		if s == nil {
-			return nil, fmt.Errorf("gui script '%s' is nil", k)
+	}
+	if !found {
+		return fmt.Errorf("%s does not match any command", PyProjectKey("tool", "pybundler", "commands", name))
+	}
+	return nil
+}
+
+// Runnable returns every command in the collection that has an entry point.
+func (sc *CommandCollection) Runnable() []*Command {
+	runnable := make([]*Command, 0)
+	for _, group := range sc.Groups() {
+		group.Walk(func(c *Command) {
+			if c.Entry != nil {
+				runnable = append(runnable, c)
+			}
+		})
+	}
+	return runnable
+}
+
+// Trees returns the top level commands of the collection.
+func (sc *CommandCollection) Trees() []*Command {
+	return []*Command{sc.Scripts, sc.GuiScripts, sc.EntryPoints, sc.Apps}
+}
+
+// Groups returns the top level commands that contain or run commands.
+func (sc *CommandCollection) Groups() []*Command {
+	groups := make([]*Command, 0)
+	for _, g := range sc.Trees() {
+		if g != nil && (g.IsGroup() || g.IsRunnable()) {
+			groups = append(groups, g)
 		}
-		sc.GuiScripts = append(sc.GuiScripts, s)
 	}
+	return groups
+}
 
-	return &sc, nil
+// Root returns the root of the generated command tree. When the project
+// defines a single command, the root command runs it directly. Otherwise
+// the scripts, gui scripts, entry points and the serve command become
+// commands below the root.
+func (sc *CommandCollection) Root() (*Command, error) {
+	runnable := sc.Runnable()
+	switch {
+	case len(runnable) == 0:
+		return nil, fmt.Errorf("no commands found")
+	case len(runnable) == 1 && runnable[0].Server == "":
+		root := NewRootCommand(sc.AppName)
+		root.Entry = runnable[0].Entry
+		root.PyArgs = runnable[0].PyArgs
+		root.Origin = runnable[0].Origin
+		root.Short = runnable[0].Short
+		root.Long = runnable[0].Long
+		root.Snapshot = runnable[0].Snapshot
+		root.Usage = runnable[0].Usage
+		root.Flags = runnable[0].Flags
+		root.Prog = runnable[0].Prog
+		root.Completion = runnable[0].Completion
+		for _, child := range runnable[0].Children {
+			root.AddCommand(child)
+		}
+		return root, nil
+	}
+	return NewRootCommand(sc.AppName, sc.Groups()...), nil
+}
+
+// Extras returns the sorted set of extras referenced by the entry points in
+// the collection.
+func (sc *CommandCollection) Extras() []string {
+	extras := make([]string, 0)
+	for _, c := range sc.Runnable() {
+		extras = append(extras, c.Entry.Extras...)
+	}
+	slices.Sort(extras)
+	return slices.Compact(extras)
 }
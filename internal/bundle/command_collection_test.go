@@ -0,0 +1,196 @@
+package bundle_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func commandPaths(c *bundle.Command) []string {
+	paths := make([]string, 0)
+	c.Walk(func(c *bundle.Command) {
+		if c.IsRunnable() {
+			paths = append(paths, strings.Join(c.Path(), " ")+" -> "+c.Dir())
+		}
+	})
+	return paths
+}
+
+func TestNewCommandCollectionNestsCommands(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+		Name: "my-app",
+		Scripts: map[string]string{
+			"db":             "app.db:main",
+			"db.migrate":     "app.db:migrate",
+			"db.seed":        "app.db:seed",
+			"db seed_all":    "app.db:seed_all",
+			"cache.l1.clear": "app.cache:clear",
+		},
+	}}
+	sc, err := bundle.NewCommandCollection(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"scripts cache l1 clear -> internal/scripts/cache/l1/clear_cmd",
+		"scripts db -> internal/scripts/db",
+		"scripts db migrate -> internal/scripts/db/migrate",
+		"scripts db seed -> internal/scripts/db/seed",
+		"scripts db seed-all -> internal/scripts/db/seed_all",
+	}
+	root, err := sc.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := commandPaths(root)
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	db := sc.Scripts.Child("db")
+	if !db.IsRunnable() || !db.IsGroup() {
+		t.Fatalf("expected db to be runnable and to have sub commands")
+	}
+	if db.PyArgs[0] != "-c" || !strings.Contains(db.PyArgs[1], "sys.argv[0] = 'db'") {
+		t.Fatalf("unexpected python arguments %v", db.PyArgs)
+	}
+}
+
+func TestNewCommandCollectionSeparator(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+		Name:    "my-app",
+		Scripts: map[string]string{"db:migrate": "app:main", "my.tool": "app:main"},
+	}}
+	sep := ":"
+	pyproject.Tool.PyBundler.CommandSeparator = &sep
+	sc, err := bundle.NewCommandCollection(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Scripts.Child("db").Child("migrate") == nil || sc.Scripts.Child("my.tool") == nil {
+		t.Fatalf("unexpected command tree %v", commandPaths(sc.Scripts))
+	}
+}
+
+func TestNewCommandCollectionSingleCommand(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+		Name:    "my-app",
+		Scripts: map[string]string{"db.migrate": "app:main"},
+	}}
+	sc, err := bundle.NewCommandCollection(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := sc.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.IsRoot() || !root.IsRunnable() || root.IsGroup() {
+		t.Fatalf("expected a runnable root command without sub commands")
+	}
+}
+
+func TestNewCommandCollectionLegacyScripts(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+		Name:       "my-app",
+		Scripts:    map[string]string{"cli": "app.cli:main"},
+		GuiScripts: map[string]string{},
+		EntryPoints: map[string]map[string]string{
+			"console_scripts": {"cli": "app.legacy:main", "db": "app.db:main"},
+			"gui_scripts":     {"viewer": "app.gui:main"},
+		},
+	}}
+	sc, err := bundle.NewCommandCollection(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := sc.Scripts.Child("cli"); c == nil || c.Entry.Module != "app.cli" {
+		t.Fatalf("expected cli to be taken from project.scripts")
+	}
+	if c := sc.Scripts.Child("db"); c == nil || c.Origin != "project.entry-points.console_scripts.db" {
+		t.Fatalf("expected db to be taken from the console_scripts entry points")
+	}
+	if sc.GuiScripts.Child("viewer") == nil {
+		t.Fatalf("expected viewer to be a gui script")
+	}
+	if sc.EntryPoints.IsGroup() {
+		t.Fatalf("expected no entry point groups, got %v", commandPaths(sc.EntryPoints))
+	}
+}
+
+func TestNewCommandCollectionRejectsEmptyNames(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+		Name:    "my-app",
+		Scripts: map[string]string{"db..migrate": "app:main"},
+	}}
+	_, err := bundle.NewCommandCollection(pyproject)
+	if err == nil || !strings.Contains(err.Error(), "empty command name") {
+		t.Fatalf("expected empty command name error, got %v", err)
+	}
+}
+
+func TestNewCommandCollectionCommandMetadata(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+		Name: "my-app",
+		Scripts: map[string]string{
+			"build":      "app:build",
+			"db.migrate": "app.db:migrate",
+			"db.seed":    "app.db:seed",
+			"deploy":     "app:deploy",
+		},
+	}}
+	first, second := 1, 2
+	pyproject.Tool.PyBundler.Commands = map[string]bundle.CommandConfig{
+		"deploy":     {Short: "Deploy the app", Aliases: []string{"ship"}, Group: "Release", Order: &first},
+		"db":         {Short: "Manage the database", Order: &second},
+		"db.seed":    {Hidden: true},
+		"db.migrate": {Long: "Run all pending migrations."},
+	}
+	sc, err := bundle.NewCommandCollection(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := make([]string, 0)
+	for _, c := range sc.Scripts.Children {
+		order = append(order, c.Name)
+	}
+	if want := []string{"deploy", "db", "build"}; !slices.Equal(order, want) {
+		t.Fatalf("got order %v, want %v", order, want)
+	}
+	deploy := sc.Scripts.Child("deploy")
+	if deploy.Short != "Deploy the app" || !slices.Equal(deploy.Aliases, []string{"ship"}) || deploy.Group != "Release" {
+		t.Fatalf("unexpected metadata %+v", deploy)
+	}
+	if groups := sc.Scripts.Groups(); !slices.Equal(groups, []string{"Release"}) {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	if !sc.Scripts.Lookup([]string{"db", "seed"}).Hidden {
+		t.Fatalf("expected db seed to be hidden")
+	}
+
+	deploy.Short = ""
+	deploy.SetDocstring("Deploy it.\n\nUploads the build.")
+	if deploy.Short != "Deploy it." || deploy.Long != "Deploy it.\n\nUploads the build." {
+		t.Fatalf("unexpected descriptions %q, %q", deploy.Short, deploy.Long)
+	}
+}
+
+func TestNewCommandCollectionCommandMetadataErrors(t *testing.T) {
+	cases := map[string]map[string]bundle.CommandConfig{
+		"does not match any command": {"missing": {Short: "Missing"}},
+		"is used by both":            {"deploy": {Aliases: []string{"build"}}},
+	}
+	for want, commands := range cases {
+		pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+			Name:    "my-app",
+			Scripts: map[string]string{"build": "app:build", "deploy": "app:deploy"},
+		}}
+		pyproject.Tool.PyBundler.Commands = commands
+		_, err := bundle.NewCommandCollection(pyproject)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
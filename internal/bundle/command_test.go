@@ -0,0 +1,109 @@
+package bundle_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestNewLauncher(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	dir := t.TempDir()
+	src := `import asyncio
+
+def ok():
+    print("ok")
+
+def fail():
+    return 3
+
+def message():
+    return "boom"
+
+async def coro():
+    await asyncio.sleep(0)
+    return 4
+
+def sys():
+    return 6
+
+def inspect():
+    return 7
+
+class Tool:
+    @staticmethod
+    def run():
+        import sys as system
+        return 0 if system.argv[0] == "my tool" else 5
+`
+	err = os.WriteFile(filepath.Join(dir, "launcher_mod.py"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		attr string
+		code int
+	}{
+		{"ok", 0},
+		{"fail", 3},
+		{"message", 1},
+		{"coro", 4},
+		{"Tool.run", 0},
+		{"sys", 6},
+		{"inspect", 7},
+	}
+	for _, c := range cases {
+		t.Run(c.attr, func(t *testing.T) {
+			cmd := exec.Command(python, "-c", bundle.NewLauncher("my tool", "launcher_mod", c.attr))
+			cmd.Dir = dir
+			err := cmd.Run()
+			code := 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				code = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if code != c.code {
+				t.Fatalf("expected exit code %d, got %d", c.code, code)
+			}
+		})
+	}
+}
+
+func TestPyString(t *testing.T) {
+	cases := map[string]string{
+		"plain":     `'plain'`,
+		"it's":      `'it\'s'`,
+		`back\lash`: `'back\\lash'`,
+		"new\nline": `'new\nline'`,
+		"café":      `'café'`,
+	}
+	for in, want := range cases {
+		if got := bundle.PyString(in); got != want {
+			t.Errorf("PyString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestCompleteVars(t *testing.T) {
+	cases := map[string][]string{
+		"my-tool":    {"_MY_TOOL_COMPLETE"},
+		"db.migrate": {"_DB.MIGRATE_COMPLETE", "_DB_MIGRATE_COMPLETE"},
+	}
+	for prog, want := range cases {
+		c := &bundle.Command{Prog: prog}
+		if got := c.CompleteVars(); !slices.Equal(got, want) {
+			t.Errorf("CompleteVars() for %q = %v, want %v", prog, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package bundle
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// EntryPoint is an entry point object reference of the form
+// `module:attr.attr [extra1, extra2]` as described by the entry points
+// specification. Attr is empty for module-only references.
+type EntryPoint struct {
+	Module string
+	Attr   string
+	Extras []string
+}
+
+// namePattern matches valid project and extra names as defined by PEP 508.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+
+func ParseEntryPoint(value string) (*EntryPoint, error) {
+	ref := strings.TrimSpace(value)
+	if ref == "" {
+		return nil, fmt.Errorf("empty object reference")
+	}
+
+	ep := &EntryPoint{}
+	if i := strings.IndexByte(ref, '['); i >= 0 {
+		if !strings.HasSuffix(ref, "]") {
+			return nil, fmt.Errorf("invalid object reference %q: unterminated extras", value)
+		}
+		extras, err := parseExtras(ref[i+1 : len(ref)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid object reference %q: %v", value, err)
+		}
+		ep.Extras = extras
+		ref = strings.TrimSpace(ref[:i])
+	}
+
+	module, attr, hasAttr := strings.Cut(ref, ":")
+	ep.Module = strings.TrimSpace(module)
+	ep.Attr = strings.TrimSpace(attr)
+	if !isDottedName(ep.Module) {
+		return nil, fmt.Errorf("invalid object reference %q: %q is not a valid module name", value, ep.Module)
+	}
+	if hasAttr && !isDottedName(ep.Attr) {
+		return nil, fmt.Errorf("invalid object reference %q: %q is not a valid attribute", value, ep.Attr)
+	}
+	return ep, nil
+}
+
+// PythonArgs returns the interpreter arguments that run the entry point.
+// Module-only references are run like `python -m module`.
+func (ep *EntryPoint) PythonArgs(prog string) []string {
+	if ep.Attr == "" {
+		return []string{"-m", ep.Module}
+	}
+	return []string{"-c", NewLauncher(prog, ep.Module, ep.Attr)}
+}
+
+func (ep *EntryPoint) String() string {
+	s := ep.Module
+	if ep.Attr != "" {
+		s += ":" + ep.Attr
+	}
+	if len(ep.Extras) > 0 {
+		s += " [" + strings.Join(ep.Extras, ", ") + "]"
+	}
+	return s
+}
+
+func parseExtras(s string) ([]string, error) {
+	extras := make([]string, 0)
+	if strings.TrimSpace(s) == "" {
+		return extras, nil
+	}
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if !namePattern.MatchString(e) {
+			return nil, fmt.Errorf("%q is not a valid extra name", e)
+		}
+		extras = append(extras, NormalizeName(e))
+	}
+	return extras, nil
+}
+
+func isDottedName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, part := range strings.Split(s, ".") {
+		if !isPythonIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPythonIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+var normalizePattern = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName normalizes a project or extra name as described in PEP 503.
+func NormalizeName(name string) string {
+	return strings.ToLower(normalizePattern.ReplaceAllString(name, "-"))
+}
@@ -0,0 +1,86 @@
+package bundle_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestParseEntryPoint(t *testing.T) {
+	cases := []struct {
+		value  string
+		module string
+		attr   string
+		extras []string
+	}{
+		{"pkg:main", "pkg", "main", nil},
+		{"pkg.mod:main", "pkg.mod", "main", nil},
+		{" pkg.mod : main ", "pkg.mod", "main", nil},
+		{"pkg.mod:Class.method", "pkg.mod", "Class.method", nil},
+		{"pkg.mod:mod.main", "pkg.mod", "mod.main", nil},
+		{"pkg.__main__", "pkg.__main__", "", nil},
+		{"pkg:main [cli]", "pkg", "main", []string{"cli"}},
+		{"pkg:main[cli, S3_Extra]", "pkg", "main", []string{"cli", "s3-extra"}},
+		{"pkg [cli]", "pkg", "", []string{"cli"}},
+		{"pkg:main []", "pkg", "main", []string{}},
+		{"café:entrée", "café", "entrée", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			ep, err := bundle.ParseEntryPoint(c.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ep.Module != c.module || ep.Attr != c.attr {
+				t.Fatalf("got module=%q attr=%q, want module=%q attr=%q", ep.Module, ep.Attr, c.module, c.attr)
+			}
+			if len(ep.Extras) != len(c.extras) || !slices.Equal(ep.Extras, c.extras) {
+				t.Fatalf("got extras %v, want %v", ep.Extras, c.extras)
+			}
+		})
+	}
+}
+
+func TestParseEntryPointInvalid(t *testing.T) {
+	cases := []string{
+		"",
+		":main",
+		"pkg:",
+		"pkg:main:other",
+		"1pkg:main",
+		"pkg-name:main",
+		"pkg:main()",
+		"pkg:main [cli",
+		"pkg:main [c li]",
+		"pkg:main [cli,]",
+		"pkg..mod:main",
+		"pkg; import os:main",
+	}
+	for _, value := range cases {
+		t.Run(value, func(t *testing.T) {
+			if ep, err := bundle.ParseEntryPoint(value); err == nil {
+				t.Fatalf("expected error, got %+v", ep)
+			}
+		})
+	}
+}
+
+func TestEntryPointPythonArgs(t *testing.T) {
+	ep, err := bundle.ParseEntryPoint("pkg.__main__")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args := ep.PythonArgs("tool"); !slices.Equal(args, []string{"-m", "pkg.__main__"}) {
+		t.Fatalf("unexpected args for module reference: %v", args)
+	}
+
+	ep, err = bundle.ParseEntryPoint("pkg.cli:main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := ep.PythonArgs("tool")
+	if len(args) != 2 || args[0] != "-c" || args[1] != bundle.NewLauncher("tool", "pkg.cli", "main") {
+		t.Fatalf("unexpected args for attribute reference: %v", args)
+	}
+}
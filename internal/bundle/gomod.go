@@ -0,0 +1,164 @@
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The Go dependencies of the generated project are pinned by each release,
+// and go.sum is rendered with their checksums.
+const (
+	GO_VERSION              = "1.26"
+	COBRA_VERSION           = "v1.9.1"
+	GO_EMBED_PYTHON_VERSION = "v0.0.0-3.14.6-20260610-1"
+)
+
+// GO_IMPORTS are the packages the generated project imports from its Go
+// dependencies.
+var GO_IMPORTS = []string{
+	"github.com/kluctl/go-embed-python/embed_util",
+	"github.com/kluctl/go-embed-python/python",
+	"github.com/spf13/cobra",
+}
+
+// GoDependency is a module in the dependency graph of the generated
+// project, with its go.sum checksums.
+type GoDependency struct {
+	Path    string
+	Version string
+
+	// Sum is the checksum of the module, or empty when only its go.mod is
+	// read, and GoModSum the checksum of its go.mod.
+	Sum      string
+	GoModSum string
+
+	// Indirect is set for the modules required as indirect dependencies in
+	// go.mod.
+	Indirect bool
+
+	// Pinned is set for the dependencies of the pinned go-embed-python,
+	// which are left to `go mod tidy` for other versions.
+	Pinned bool
+}
+
+// GO_DEPENDENCIES are the modules of the pinned Go dependencies.
+var GO_DEPENDENCIES = []GoDependency{
+	{Path: "github.com/spf13/cobra", Version: COBRA_VERSION, Sum: "h1:CXSaggrXdbHK9CF+8ywj8Amf7PBRmPCOJugH954Nnlo=", GoModSum: "h1:nDyEzZ8ogv936Cinf6g1RU9MRY64Ir93oCnqb9wxYW0="},
+	{Path: "github.com/cpuguy83/go-md2man/v2", Version: "v2.0.6", GoModSum: "h1:oOW0eioCTA6cOiMLiUPZOpcVxMig6NIQQ7OS05n1F4g="},
+	{Path: "github.com/inconshreveable/mousetrap", Version: "v1.1.0", Sum: "h1:wN+x4NVGpMsO7ErUn/mUI3vEoE6Jt13X2s0bqwp9tc8=", GoModSum: "h1:vpF70FUmC8bwa3OWnCshd2FqLfsEA9PFc4w1p2J65bw=", Indirect: true},
+	{Path: "github.com/russross/blackfriday/v2", Version: "v2.1.0", GoModSum: "h1:+Rmxgy9KzJVeS9/2gXHxylqXiyQDYRxCVz55jmeOWTM="},
+	{Path: "github.com/spf13/pflag", Version: "v1.0.6", Sum: "h1:jFzHGLGAlb3ruxLB8MhbI6A8+AQX/2eW4qeyNZXNp2o=", GoModSum: "h1:McXfInJRrz4CZXVZOBLb0bTZqETkiAhM9Iw0y3An2Bg=", Indirect: true},
+	{Path: "gopkg.in/check.v1", Version: "v0.0.0-20161208181325-20d25e280405", GoModSum: "h1:Co6ibVJAznAaIkqp8huTwlJQCZ016jof/cbN4VW5Yz0="},
+	{Path: "gopkg.in/yaml.v3", Version: "v3.0.1", Sum: "h1:fxVm/GzAzEWqLHuvctI91KS9hhNmmWOoWu0XTYJS7CA=", GoModSum: "h1:K4uyk7z7BCEPqu6E+C64Yfv1cQ7kz7rIZviUmN+EgEM="},
+
+	{Path: "github.com/kluctl/go-embed-python", Version: GO_EMBED_PYTHON_VERSION, Sum: "h1:oGUS7++Wm3LgxUfD6AmJgKbKQD2wdQFO9PzyJv6T+E4=", GoModSum: "h1:nMLEqpwngR8gAq3WFt2XjstgEjHrWtOnTv8gmUcxIik=", Pinned: true},
+	{Path: "github.com/davecgh/go-spew", Version: "v1.1.1", Sum: "h1:vj9j/u1bqnvCEfJOwUhtlOARqs3+rkHYY13jYWTU97c=", GoModSum: "h1:J7Y8YcW2NihsgmVo/mv3lAwl/skON4iLHjSsI+c5H38=", Pinned: true},
+	{Path: "github.com/gobwas/glob", Version: "v0.2.3", Sum: "h1:A4xDbljILXROh+kObIiy5kIaPYD8e96x1tgBhUI5J+Y=", GoModSum: "h1:d3Ez4x06l9bZtSvzIay5+Yzi0fmZzPgnTbPcKjJAkT8=", Pinned: true},
+	{Path: "github.com/gofrs/flock", Version: "v0.13.0", Sum: "h1:95JolYOvGMqeH31+FC7D2+uULf6mG61mEZ/A8dRYMzw=", GoModSum: "h1:jxeyy9R1auM5S6JYDBhDt+E2TCo7DkratH4Pgi8P+Z0=", Indirect: true, Pinned: true},
+	{Path: "github.com/pmezard/go-difflib", Version: "v1.0.0", Sum: "h1:4DBwDE0NGyQoBHbLQYPwSUPoCMWR5BEzIk/f1lZbAQM=", GoModSum: "h1:iKH77koFhYxTK1pcRnkKkqfTogsbg7gZNVY4sRDYZ/4=", Pinned: true},
+	{Path: "github.com/sirupsen/logrus", Version: "v1.9.4", Sum: "h1:TsZE7l11zFCLZnZ+teH4Umoq5BhEIfIzfRDZ1Uzql2w=", GoModSum: "h1:ftWc9WdOfJ0a92nsE2jF5u5ZwH8Bv2zdeOC42RjbV2g=", Indirect: true, Pinned: true},
+	{Path: "github.com/stretchr/testify", Version: "v1.11.1", Sum: "h1:7s2iGBzp5EwR7/aIZr8ao5+dra3wiQyKjjFuvgVKu7U=", GoModSum: "h1:wZwfW3scLgRK+23gO65QZefKpKQRnfz6sD981Nm4B6U=", Pinned: true},
+	{Path: "golang.org/x/sync", Version: "v0.21.0", Sum: "h1:HLII4xRRTtCRkxYp4HNFF0Js/Og6q2i++KXbg0gHCwM=", GoModSum: "h1:9xrNwdLfx4jkKbNva9FpL6vEN7evnE43NNNJQ2LF3+0=", Indirect: true, Pinned: true},
+	{Path: "golang.org/x/sys", Version: "v0.46.0", Sum: "h1:noSf2Fq6F8DBgS+LysIkx7rIExoNHJsxOAtPp4rthXw=", GoModSum: "h1:4GL1E5IUh+htKOUEOaiffhrAeqysfVGipDYzABqnCmw=", Indirect: true, Pinned: true},
+}
+
+// WithGoEmbedPython overrides the pinned go-embed-python version, which
+// also selects the embedded Python version.
+func WithGoEmbedPython(version string) Option {
+	return func(p *PyProject) {
+		p.Tool.PyBundler.GoEmbedPython = version
+	}
+}
+
+// GoModule is the go.mod of the generated project.
+type GoModule struct {
+	Path          string
+	GoVersion     string
+	GoEmbedPython string
+	Cobra         string
+}
+
+// GoModule returns the go.mod of the generated project.
+func (bo *BundleOptions) GoModule() GoModule {
+	path, _ := GoModulePath(bo.PyProject.Project.Name)
+	module := GoModule{
+		Path:          path,
+		GoVersion:     GO_VERSION,
+		GoEmbedPython: GO_EMBED_PYTHON_VERSION,
+		Cobra:         COBRA_VERSION,
+	}
+	if v := bo.PyProject.Tool.PyBundler.GoEmbedPython; v != "" {
+		module.GoEmbedPython = v
+	}
+	return module
+}
+
+// Pinned reports whether go-embed-python is the version pinned by this
+// release. Otherwise, its requirements and checksums are left to
+// `go mod tidy`.
+func (m GoModule) Pinned() bool {
+	return m.GoEmbedPython == GO_EMBED_PYTHON_VERSION
+}
+
+// Dependencies returns the modules of GO_DEPENDENCIES that belong to m,
+// sorted by path.
+func (m GoModule) Dependencies() []GoDependency {
+	deps := slices.DeleteFunc(slices.Clone(GO_DEPENDENCIES), func(d GoDependency) bool {
+		return d.Pinned && !m.Pinned()
+	})
+	slices.SortFunc(deps, func(a, b GoDependency) int { return strings.Compare(a.Path, b.Path) })
+	return deps
+}
+
+// Sum returns the lines of go.sum in the order go writes them.
+func (m GoModule) Sum() []string {
+	lines := make([]string, 0)
+	for _, d := range m.Dependencies() {
+		if d.Sum != "" {
+			lines = append(lines, d.Path+" "+d.Version+" "+d.Sum)
+		}
+		lines = append(lines, d.Path+" "+d.Version+"/go.mod "+d.GoModSum)
+	}
+	return lines
+}
+
+// Render renders go.mod and go.sum into files.
+func (m GoModule) Render(files *RenderedFiles, dir string) error {
+	for _, name := range []string{"go.mod", "go.sum"} {
+		err := files.RenderFile(name+".tmpl", filepath.Join(dir, name), m)
+		if err != nil {
+			return fmt.Errorf("rendering %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// WriteDependencies writes a module to dir that only imports the Go
+// dependencies of the generated project, to download them or look them up
+// without rendering the project.
+func (m GoModule) WriteDependencies(dir string) error {
+	m.Path = "pybundler/dependencies"
+	files := &RenderedFiles{Root: dir}
+	err := m.Render(files, dir)
+	if err != nil {
+		return err
+	}
+	err = files.Write()
+	if err != nil {
+		return err
+	}
+	var src strings.Builder
+	src.WriteString("package main\n\nimport (\n")
+	for _, imp := range GO_IMPORTS {
+		fmt.Fprintf(&src, "\t_ %q\n", imp)
+	}
+	src.WriteString(")\n\nfunc main() {}\n")
+	err = os.WriteFile(filepath.Join(dir, "main.go"), []byte(src.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("writing Go module: %v", err)
+	}
+	return nil
+}
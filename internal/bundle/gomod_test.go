@@ -0,0 +1,88 @@
+package bundle_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestGoModule(t *testing.T) {
+	dir := writePyProject(t, testDependencySelection)
+	b, err := bundle.New(dir, filepath.Join(t.TempDir(), "out"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	module := b.GoModule()
+	if module.Path != "my-app" || module.GoEmbedPython != bundle.GO_EMBED_PYTHON_VERSION || !module.Pinned() {
+		t.Fatalf("unexpected module %+v", module)
+	}
+	files := &bundle.RenderedFiles{Root: t.TempDir()}
+	err = module.Render(files, files.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gomod, gosum := string(files.Files[0].Content), string(files.Files[1].Content)
+	for _, want := range []string{"module my-app\n", "go " + bundle.GO_VERSION + "\n", "github.com/kluctl/go-embed-python " + bundle.GO_EMBED_PYTHON_VERSION + "\n", "github.com/spf13/cobra " + bundle.COBRA_VERSION + "\n", "golang.org/x/sys"} {
+		if !strings.Contains(gomod, want) {
+			t.Errorf("expected %q in go.mod:\n%s", want, gomod)
+		}
+	}
+	for _, want := range []string{"github.com/kluctl/go-embed-python " + bundle.GO_EMBED_PYTHON_VERSION + " h1:", "github.com/spf13/cobra " + bundle.COBRA_VERSION + " h1:"} {
+		if !strings.Contains(gosum, want) {
+			t.Errorf("expected %q in go.sum:\n%s", want, gosum)
+		}
+	}
+	if lines := strings.Split(strings.TrimSuffix(gosum, "\n"), "\n"); !slices.IsSorted(lines) {
+		t.Errorf("expected go.sum to be sorted:\n%s", gosum)
+	}
+	dockerfile, err := bundle.RenderTemplate("dockerfile.tmpl", bundle.NewRootCommand("my-app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dockerfile, "FROM golang:"+bundle.GO_VERSION+" AS build-stage\n") {
+		t.Errorf("expected the Go version in the Dockerfile:\n%s", dockerfile)
+	}
+
+	b, err = bundle.New(dir, filepath.Join(t.TempDir(), "out"), false, bundle.WithGoEmbedPython("v0.0.0-3.13.5-20250612-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	module = b.GoModule()
+	if module.GoEmbedPython != "v0.0.0-3.13.5-20250612-1" || module.Pinned() {
+		t.Fatalf("unexpected module with override %+v", module)
+	}
+	if python := b.Manifest().Python; python != "3.13.5" {
+		t.Fatalf("unexpected Python version %s", python)
+	}
+	files = &bundle.RenderedFiles{Root: t.TempDir()}
+	err = module.Render(files, files.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gosum := string(files.Files[1].Content); strings.Contains(gosum, "go-embed-python") {
+		t.Fatalf("unexpected go-embed-python checksum with override:\n%s", gosum)
+	}
+
+	_, err = bundle.New(dir, filepath.Join(t.TempDir(), "out"), false, bundle.WithGoEmbedPython("latest"))
+	if err == nil {
+		t.Fatal("expected error for a go-embed-python version without a Python version")
+	}
+}
+
+func TestWriteDependencies(t *testing.T) {
+	dir := t.TempDir()
+	module := bundle.GoModule{GoVersion: bundle.GO_VERSION, GoEmbedPython: bundle.GO_EMBED_PYTHON_VERSION, Cobra: bundle.COBRA_VERSION}
+	err := module.WriteDependencies(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"go.mod", "go.sum", "main.go"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// REQUIRE_HASHES is the requirements.txt option that makes the installer
+// verify every downloaded artifact against the hashes of its requirement.
+const REQUIRE_HASHES = "--require-hashes"
+
+// hashMismatchPattern matches the packages uv reports when downloaded
+// artifacts do not match the hashes of their requirements.
+var hashMismatchPattern = regexp.MustCompile("Hash mismatch for `([^`]+)`")
+
+// RequirementLines returns the logical lines of a requirements file, joining
+// lines continued with a backslash, like the hashes written by `uv export`.
+func RequirementLines(pkgReqs []byte) []string {
+	lines := make([]string, 0)
+	var current strings.Builder
+	for _, line := range strings.Split(string(pkgReqs), "\n") {
+		line = strings.TrimSpace(line)
+		continued := strings.HasSuffix(line, "\\")
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\\"))
+		if current.Len() > 0 && line != "" {
+			current.WriteByte(' ')
+		}
+		current.WriteString(line)
+		if continued {
+			continue
+		}
+		if current.Len() > 0 {
+			lines = append(lines, current.String())
+		}
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		lines = append(lines, current.String())
+	}
+	return lines
+}
+
+// HasHashes reports whether a requirement line pins at least one hash.
+func HasHashes(line string) bool {
+	return strings.Contains(line, "--hash=")
+}
+
+// FileHash returns the pip hash option of the file at path.
+func FileHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %v", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return "--hash=sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// HashMismatches returns the requirements uv reported in output as not
+// matching the hashes they were pinned with. Packages reported for several
+// platforms are only returned once.
+func HashMismatches(output string) []string {
+	pkgs := make([]string, 0)
+	for _, m := range hashMismatchPattern.FindAllStringSubmatch(output, -1) {
+		if !slices.Contains(pkgs, m[1]) {
+			pkgs = append(pkgs, m[1])
+		}
+	}
+	return pkgs
+}
@@ -0,0 +1,83 @@
+package bundle_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+const testHashedRequirements = `# This file was autogenerated by uv via the following command:
+#    uv export --no-emit-project
+click==8.1.8 \
+    --hash=sha256:63c132bbbed01578a06712a2d1f497bb62d9c1c0d329b7903a866228027263b2 \
+    --hash=sha256:ed53c9d8990d83c2a27deae68e4ee337473f6330c040a31d4225c9574d16096a
+colorama==0.4.6 ; sys_platform == 'win32' \
+    --hash=sha256:08695f5cb7ed6e0531a20572697297273c47b8cae5a63ffc6d6ed5c201be6e44
+    # via click
+-e ./packages/lib
+`
+
+func TestRequirementLines(t *testing.T) {
+	lines := bundle.RequirementLines([]byte(testHashedRequirements))
+	want := []string{
+		"# This file was autogenerated by uv via the following command:",
+		"#    uv export --no-emit-project",
+		"click==8.1.8 --hash=sha256:63c132bbbed01578a06712a2d1f497bb62d9c1c0d329b7903a866228027263b2 --hash=sha256:ed53c9d8990d83c2a27deae68e4ee337473f6330c040a31d4225c9574d16096a",
+		"colorama==0.4.6 ; sys_platform == 'win32' --hash=sha256:08695f5cb7ed6e0531a20572697297273c47b8cae5a63ffc6d6ed5c201be6e44",
+		"# via click",
+		"-e ./packages/lib",
+	}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+	if !bundle.HasHashes(lines[2]) || bundle.HasHashes(lines[5]) {
+		t.Fatal("expected only pinned requirements to have hashes")
+	}
+	names := bundle.RequirementNames([]byte(testHashedRequirements))
+	if len(names) != 2 || !names["click"] || !names["colorama"] {
+		t.Fatalf("unexpected requirement names %v", names)
+	}
+	if p, ok := bundle.LocalRequirement("lib @ file:///tmp/lib-1.0-py3-none-any.whl --hash=sha256:00", "/"); !ok || p != filepath.FromSlash("/tmp/lib-1.0-py3-none-any.whl") {
+		t.Fatalf("unexpected local requirement %q", p)
+	}
+}
+
+func TestFileHash(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "hello.txt")
+	err := os.WriteFile(p, []byte("hello\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := bundle.FileHash(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != "--hash=sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03" {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+}
+
+func TestHashMismatches(t *testing.T) {
+	output := `  × Failed to download ` + "`click==8.1.8`" + `
+  ╰─▶ Hash mismatch for ` + "`click==8.1.8`" + `
+
+      Expected:
+        sha256:63c132bbbed01578a06712a2d1f497bb62d9c1c0d329b7903a866228027263b2
+
+      Computed:
+        sha256:0000000000000000000000000000000000000000000000000000000000000000
+  × Failed to read ` + "`demo @ file:///tmp/demo-1.0-py3-none-any.whl`" + `
+  ╰─▶ Hash mismatch for ` + "`demo @ file:///tmp/demo-1.0-py3-none-any.whl`" + `
+  × Failed to download ` + "`click==8.1.8`" + `
+  ╰─▶ Hash mismatch for ` + "`click==8.1.8`" + `
+`
+	if pkgs := bundle.HashMismatches(output); !slices.Equal(pkgs, []string{"click==8.1.8", "demo @ file:///tmp/demo-1.0-py3-none-any.whl"}) {
+		t.Fatalf("unexpected mismatches %v", pkgs)
+	}
+	if pkgs := bundle.HashMismatches("  × No solution found when resolving dependencies:\n  ╰─▶ Because click==8.1.8 has no wheels with a matching platform tag\n"); len(pkgs) != 0 {
+		t.Fatalf("expected no mismatches, got %v", pkgs)
+	}
+}
@@ -0,0 +1,156 @@
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// envReferencePattern matches the environment variable references pip
+// expands in requirements files.
+var envReferencePattern = regexp.MustCompile(`\$\{[A-Z0-9_]+\}`)
+
+// IndexSection configures where dependencies are resolved and downloaded
+// from. URLs may reference environment variables like ${INDEX_TOKEN}, which
+// are expanded by uv and pip when they run, so that credentials are never
+// written to the generated project. Credentials can also be read from
+// netrc.
+type IndexSection struct {
+	IndexURL      string   `toml:"index-url"`
+	ExtraIndexURL []string `toml:"extra-index-url"`
+	FindLinks     []string `toml:"find-links"`
+
+	// Cert is the CA bundle used to verify the indexes.
+	Cert string `toml:"cert"`
+}
+
+// WithIndex overrides the indexes, find-links and certificate configured in
+// [tool.pybundler] with those set in index.
+func WithIndex(index IndexSection) Option {
+	return func(p *PyProject) {
+		pb := &p.Tool.PyBundler
+		if index.IndexURL != "" {
+			pb.IndexURL = index.IndexURL
+		}
+		if len(index.ExtraIndexURL) > 0 {
+			pb.ExtraIndexURL = index.ExtraIndexURL
+		}
+		if len(index.FindLinks) > 0 {
+			pb.FindLinks = index.FindLinks
+		}
+		if index.Cert != "" {
+			pb.Cert = index.Cert
+		}
+	}
+}
+
+// Resolve returns index with relative find-links directories and
+// certificate path resolved against dir.
+func (index IndexSection) Resolve(dir string) (IndexSection, error) {
+	resolve := func(p string) (string, error) {
+		if p == "" || strings.Contains(p, "://") || filepath.IsAbs(p) {
+			return p, nil
+		}
+		return filepath.Abs(filepath.Join(dir, p))
+	}
+	cert, err := resolve(index.Cert)
+	if err != nil {
+		return index, fmt.Errorf("resolving certificate %s: %v", index.Cert, err)
+	}
+	index.Cert = cert
+	findLinks := make([]string, 0, len(index.FindLinks))
+	for _, link := range index.FindLinks {
+		resolved, err := resolve(link)
+		if err != nil {
+			return index, fmt.Errorf("resolving find-links %s: %v", link, err)
+		}
+		findLinks = append(findLinks, resolved)
+	}
+	index.FindLinks = findLinks
+	return index, nil
+}
+
+// Validate checks that no URL contains a password or token, other than
+// references to environment variables.
+func (index IndexSection) Validate() error {
+	urls := append([]string{index.IndexURL}, index.ExtraIndexURL...)
+	urls = append(urls, index.FindLinks...)
+	for _, u := range urls {
+		_, rest, ok := strings.Cut(u, "://")
+		if !ok {
+			continue
+		}
+		host, _, _ := strings.Cut(rest, "/")
+		at := strings.LastIndex(host, "@")
+		if at < 0 {
+			continue
+		}
+		// A literal user name is fine as long as the password, or a token
+		// given as the user name, is read from the environment.
+		secret := host[:at]
+		if _, password, ok := strings.Cut(secret, ":"); ok {
+			secret = password
+		}
+		if envReferencePattern.ReplaceAllString(secret, "") != "" {
+			return fmt.Errorf("index URL %s contains credentials, reference them as ${VARIABLE} or use netrc instead", u)
+		}
+	}
+	if index.Cert != "" {
+		if _, err := os.Stat(index.Cert); err != nil {
+			return fmt.Errorf("certificate %s: %v", index.Cert, err)
+		}
+	}
+	return nil
+}
+
+// Environ returns the environment variables that configure the indexes,
+// find-links and certificate for the uv and pip commands run by the
+// bundler.
+func (index IndexSection) Environ() ([]string, error) {
+	err := index.Validate()
+	if err != nil {
+		return nil, err
+	}
+	env := make([]string, 0)
+	if index.IndexURL != "" {
+		env = append(env, "UV_INDEX_URL="+os.ExpandEnv(index.IndexURL))
+	}
+	if len(index.ExtraIndexURL) > 0 {
+		env = append(env, "UV_EXTRA_INDEX_URL="+os.ExpandEnv(strings.Join(index.ExtraIndexURL, " ")))
+	}
+	if len(index.FindLinks) > 0 {
+		env = append(env, "UV_FIND_LINKS="+os.ExpandEnv(strings.Join(index.FindLinks, ",")))
+	}
+	if index.Cert != "" {
+		env = append(env, "SSL_CERT_FILE="+index.Cert, "PIP_CERT="+index.Cert)
+	}
+	return env, nil
+}
+
+// Requirements returns the options of a requirements file that make pip
+// download from the same indexes and find-links. Environment variable
+// references are kept as they are.
+func (index IndexSection) Requirements() []string {
+	reqs := make([]string, 0)
+	if index.IndexURL != "" {
+		reqs = append(reqs, "--index-url "+index.IndexURL)
+	}
+	for _, u := range index.ExtraIndexURL {
+		reqs = append(reqs, "--extra-index-url "+u)
+	}
+	for _, link := range index.FindLinks {
+		reqs = append(reqs, "--find-links "+link)
+	}
+	return reqs
+}
+
+// indexOptions returns the index configuration of p with opts applied,
+// resolved against dir, without changing p.
+func indexOptions(p PyProject, dir string, opts []Option) (IndexSection, error) {
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p.Tool.PyBundler.IndexSection.Resolve(dir)
+}
@@ -0,0 +1,189 @@
+package bundle
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+//go:embed python/introspect.py
+var introspectScript string
+
+// HelpSnapshot describes the command line interface of a Click, Typer or
+// argparse entry point as seen at build time.
+type HelpSnapshot struct {
+	Name string `json:"name"`
+	// Framework is one of "click", "typer" or "argparse".
+	Framework string          `json:"framework"`
+	Short     string          `json:"short"`
+	Long      string          `json:"long"`
+	Usage     string          `json:"usage"`
+	Hidden    bool            `json:"hidden"`
+	Flags     []Flag          `json:"flags"`
+	Commands  []*HelpSnapshot `json:"commands"`
+}
+
+// Flag is an option of a Python command line interface. Flags are
+// registered on the generated commands for help and completion only; the
+// arguments are always parsed by Python.
+type Flag struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand"`
+	Usage     string `json:"usage"`
+	// Type is the kind of value the flag takes and empty for boolean flags.
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Hidden  bool   `json:"hidden"`
+}
+
+// completeInstructions are the values of the shell completion variable
+// that ask a Click or Typer application for bash completions.
+var completeInstructions = map[string]string{
+	"click": "bash_complete",
+	"typer": "complete_bash",
+}
+
+// INTROSPECT_TIMEOUT is how long an entry point may run before its
+// argparse parser is captured.
+const INTROSPECT_TIMEOUT = 30 * time.Second
+
+// Introspection is what is learned about an entry point by importing it.
+type Introspection struct {
+	Doc  string        `json:"doc"`
+	Help *HelpSnapshot `json:"help"`
+}
+
+// Introspect imports the entry points of cmds with the python command, run
+// with env added to its environment, and returns their docstrings and help
+// snapshots. Entry points are only called for commands with Introspect set,
+// each in a process of its own that is killed after INTROSPECT_TIMEOUT, to
+// capture the argparse parser they build. Failing to capture a parser is
+// logged.
+func Introspect(cwd string, verbose bool, python []string, env []string, cmds []*Command) ([]Introspection, error) {
+	res, err := introspect(cwd, verbose, python, cmds, false, CmdOptions{Env: env})
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range cmds {
+		if !c.Introspect || res[i].Help != nil {
+			continue
+		}
+		captured, err := introspect(cwd, verbose, python, cmds[i:i+1], true, CmdOptions{Env: env, Timeout: INTROSPECT_TIMEOUT})
+		if err != nil {
+			slog.Warn("Could not capture the command line interface", "command", c.Name, "error", err)
+			continue
+		}
+		res[i].Help = captured[0].Help
+	}
+	return res, nil
+}
+
+// introspect runs the introspection script for cmds with opts, calling
+// plain functions when call is set.
+func introspect(cwd string, verbose bool, python []string, cmds []*Command, call bool, opts CmdOptions) ([]Introspection, error) {
+	refs := make([][]any, len(cmds))
+	for i, c := range cmds {
+		prog := c.Prog
+		if prog == "" {
+			prog = c.Name
+		}
+		refs[i] = []any{c.Entry.Module, c.Entry.Attr, prog, call}
+	}
+	arg, err := json.Marshal(refs)
+	if err != nil {
+		return nil, err
+	}
+	args := append(append([]string{}, python...), "-c", introspectScript, string(arg))
+	out, err := RunCmdWith(cwd, verbose, opts, args...)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	var res []Introspection
+	err = json.Unmarshal([]byte(lines[len(lines)-1]), &res)
+	if err != nil {
+		return nil, fmt.Errorf("decoding introspection result: %v", err)
+	}
+	if len(res) != len(cmds) {
+		return nil, fmt.Errorf("expected %d introspection results, got %d", len(cmds), len(res))
+	}
+	return res, nil
+}
+
+// Introspect describes the commands with the docstrings of their entry
+// points and adds the help snapshots of Click and Typer entry points, and of
+// argparse entry points that opted in, to the command tree. The project is
+// imported with the python command, usually `uv run`, so failing to import
+// it is logged rather than returned.
+func (sc *CommandCollection) Introspect(projectPath string, python []string, env []string, verbose bool) error {
+	cmds := make([]*Command, 0)
+	for _, c := range sc.Runnable() {
+		if c.Server == "" {
+			cmds = append(cmds, c)
+		}
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+
+	res, err := Introspect(projectPath, verbose, python, env, cmds)
+	if err != nil {
+		slog.Warn("Could not introspect entry points", "error", err)
+		return nil
+	}
+	for i, c := range cmds {
+		if c.Short == "" && c.Long == "" {
+			c.SetDocstring(res[i].Doc)
+		}
+		if res[i].Help != nil {
+			c.SetHelpSnapshot(res[i].Help)
+		}
+	}
+	return sc.ResolveNames()
+}
+
+// SetDocstring uses the first line of doc as the short description of c and
+// the whole docstring as the long description when it has more than one
+// line.
+func (c *Command) SetDocstring(doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	short, _, multiline := strings.Cut(doc, "\n")
+	c.Short = strings.TrimSpace(short)
+	if multiline {
+		c.Long = doc
+	}
+}
+
+// SetHelpSnapshot makes c answer --help from h and adds the sub commands of
+// h below c. Sub commands run the entry point of c with their name
+// prepended to the arguments. Commands already defined in pyproject.toml
+// take precedence over sub commands of the same name.
+func (c *Command) SetHelpSnapshot(h *HelpSnapshot) {
+	c.Snapshot = true
+	// cobra appends [flags] to the usage unless it is already present.
+	c.Usage = strings.Replace(h.Usage, "[OPTIONS]", "[flags]", 1)
+	c.Flags = h.Flags
+	c.Completion = completeInstructions[h.Framework]
+	for _, sub := range h.Commands {
+		if other := c.Child(sub.Name); other != nil {
+			slog.Warn("Sub command is shadowed by another command", "command", strings.Join(append(c.Path(), sub.Name), " "), "origin", other.Origin)
+			continue
+		}
+		child := NewGroup(c.AppName, sub.Name, c.Origin)
+		child.PyArgs = c.PyArgs
+		child.Prog = c.Prog
+		child.Prefix = append(slices.Clone(c.Prefix), sub.Name)
+		child.Short = sub.Short
+		child.Long = sub.Long
+		child.Hidden = sub.Hidden
+		c.AddCommand(child)
+		child.SetHelpSnapshot(sub)
+	}
+}
@@ -0,0 +1,201 @@
+package bundle_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestIntrospect(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	dir := t.TempDir()
+	src := `"""Module docs."""
+import argparse
+import sys
+print("importing", end="")
+
+def documented():
+    """Do things.
+
+    In detail.
+    """
+
+def undocumented():
+    pass
+
+class Help:
+    help = "Click style help"
+
+obj = object()
+
+def cli():
+    """Run the cli."""
+    parser = argparse.ArgumentParser(description="Manage things.")
+    parser.add_argument("-v", "--verbose", action="store_true", help="Talk more")
+    parser.add_argument("--name", default="world", help="Name (default: %(default)s)")
+    parser.add_argument("-q", action="store_true")
+    sub = parser.add_subparsers(dest="cmd")
+    run = sub.add_parser("run", help="Run it", aliases=["r"])
+    run.add_argument("target")
+    if sys.argv[0] != "my-tool":
+        raise SystemExit("unexpected program name")
+    parser.parse_args()
+    raise SystemExit("should not run")
+`
+	err = os.WriteFile(filepath.Join(dir, "doc_mod.py"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []*bundle.EntryPoint{
+		{Module: "doc_mod", Attr: "documented"},
+		{Module: "doc_mod", Attr: "undocumented"},
+		{Module: "doc_mod", Attr: "Help"},
+		{Module: "doc_mod", Attr: "obj"},
+		{Module: "doc_mod"},
+		{Module: "missing_mod", Attr: "main"},
+		{Module: "doc_mod", Attr: "cli"},
+	}
+	cmds := make([]*bundle.Command, len(entries))
+	for i, ep := range entries {
+		cmds[i] = &bundle.Command{Name: "tool", Entry: ep, Introspect: true}
+	}
+	cmds[6].Prog = "my-tool"
+	res, err := bundle.Introspect(dir, false, []string{python}, nil, cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := make([]string, len(res))
+	for i, r := range res {
+		docs[i] = r.Doc
+	}
+	want := []string{"Do things.\n\nIn detail.", "", "Click style help", "", "Module docs.", "", "Run the cli."}
+	if !slices.Equal(docs, want) {
+		t.Fatalf("got %q, want %q", docs, want)
+	}
+	for _, r := range res[:6] {
+		if r.Help != nil {
+			t.Fatalf("unexpected help snapshot %+v", r.Help)
+		}
+	}
+
+	h := res[6].Help
+	if h == nil {
+		t.Fatal("expected a help snapshot of the argparse entry point")
+	}
+	if h.Short != "Manage things." || h.Usage != "[OPTIONS] COMMAND [ARGS]..." {
+		t.Fatalf("unexpected snapshot %+v", h)
+	}
+	wantFlags := []bundle.Flag{
+		{Name: "verbose", Shorthand: "v", Usage: "Talk more"},
+		{Name: "name", Usage: "Name (default: world)", Type: "string", Default: "world"},
+	}
+	if !slices.Equal(h.Flags, wantFlags) {
+		t.Fatalf("got flags %+v, want %+v", h.Flags, wantFlags)
+	}
+	if h.Framework != "argparse" {
+		t.Fatalf("unexpected framework %q", h.Framework)
+	}
+	if len(h.Commands) != 1 || h.Commands[0].Name != "run" || h.Commands[0].Short != "Run it" || h.Commands[0].Usage != "TARGET" {
+		t.Fatalf("unexpected sub commands %+v", h.Commands)
+	}
+}
+
+func TestIntrospectDoesNotCall(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	dir := t.TempDir()
+	src := `import argparse
+import os
+import sys
+
+def main():
+    """Run main."""
+    open(sys.argv[0] + ".called", "w").close()
+    os._exit(3)
+
+def cli():
+    """Run the cli."""
+    argparse.ArgumentParser().parse_args()
+    os._exit(3)
+`
+	err = os.WriteFile(filepath.Join(dir, "call_mod.py"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := []*bundle.Command{
+		{Name: "main", Entry: &bundle.EntryPoint{Module: "call_mod", Attr: "main"}},
+		{Name: "cli", Entry: &bundle.EntryPoint{Module: "call_mod", Attr: "cli"}},
+		{Name: "exit", Entry: &bundle.EntryPoint{Module: "call_mod", Attr: "main"}, Introspect: true},
+	}
+	res, err := bundle.Introspect(dir, false, []string{python}, nil, cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []string{res[0].Doc, res[1].Doc, res[2].Doc}
+	if !slices.Equal(docs, []string{"Run main.", "Run the cli.", "Run main."}) {
+		t.Fatalf("unexpected docstrings %q", docs)
+	}
+	for _, r := range res {
+		if r.Help != nil {
+			t.Fatalf("unexpected help snapshot %+v", r.Help)
+		}
+	}
+	for name, want := range map[string]bool{"main": false, "exit": true} {
+		_, err := os.Stat(filepath.Join(dir, name+".called"))
+		if called := err == nil; called != want {
+			t.Errorf("expected %s to be called: %v, got %v", name, want, called)
+		}
+	}
+}
+
+func TestSetHelpSnapshot(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+		Name:    "my-app",
+		Scripts: map[string]string{"tool": "app:cli", "tool.build": "app:build"},
+	}}
+	sc, err := bundle.NewCommandCollection(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := sc.Scripts.Child("tool")
+	tool.SetHelpSnapshot(&bundle.HelpSnapshot{
+		Name:      "tool",
+		Framework: "typer",
+		Usage:     "[OPTIONS] COMMAND [ARGS]...",
+		Commands: []*bundle.HelpSnapshot{
+			{Name: "build", Short: "Shadowed"},
+			{Name: "db", Commands: []*bundle.HelpSnapshot{{Name: "migrate", Framework: "typer", Short: "Migrate", Usage: "[OPTIONS]"}}},
+		},
+	})
+	if err := sc.ResolveNames(); err != nil {
+		t.Fatal(err)
+	}
+	if build := tool.Child("build"); build.Short == "Shadowed" || build.Snapshot {
+		t.Fatalf("expected the script to take precedence over the sub command")
+	}
+	migrate := tool.Lookup([]string{"db", "migrate"})
+	if migrate == nil || !migrate.IsRunnable() || !migrate.Snapshot {
+		t.Fatalf("expected runnable sub command db migrate")
+	}
+	if !slices.Equal(migrate.Prefix, []string{"db", "migrate"}) || !slices.Equal(migrate.PyArgs, tool.PyArgs) {
+		t.Fatalf("unexpected arguments %v %v", migrate.Prefix, migrate.PyArgs)
+	}
+	if migrate.UseLine() != "migrate [flags]" || migrate.Package == "" {
+		t.Fatalf("unexpected use line %q or package %q", migrate.UseLine(), migrate.Package)
+	}
+	if migrate.Completion != "complete_bash" || migrate.Prog != "tool" {
+		t.Fatalf("unexpected completion %q for program %q", migrate.Completion, migrate.Prog)
+	}
+	if len(sc.Runnable()) != 2 {
+		t.Fatalf("expected sub commands not to count as entry points")
+	}
+}
@@ -0,0 +1,71 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MANIFEST_FILE is the name of the manifest written to the output
+// directory.
+const MANIFEST_FILE = "pybundler.json"
+
+// Manifest records what a bundle was built from.
+type Manifest struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version,omitempty"`
+	Source    string   `json:"source"`
+	Extras    []string `json:"extras"`
+	AllExtras bool     `json:"all_extras"`
+	Groups    []string `json:"groups"`
+
+	// Platforms are the platforms the dependencies are packaged for.
+	Platforms []string `json:"platforms"`
+	// Optimize is the optimization level of the precompiled bytecode.
+	Optimize int `json:"optimize"`
+	// Python is the version of the embedded Python distribution.
+	Python string `json:"python"`
+	// GoModules are the versions of the Go dependencies of the bundle.
+	GoModules map[string]string `json:"go_modules"`
+}
+
+// Manifest returns the manifest of the bundle.
+func (bo *BundleOptions) Manifest() Manifest {
+	groups := append([]string{}, bo.PyProject.Tool.PyBundler.Groups...)
+	module := bo.GoModule()
+	python, _ := PythonVersion(module.GoEmbedPython)
+	platforms := make([]string, 0)
+	selected, _ := bo.Platforms()
+	for _, p := range selected {
+		platforms = append(platforms, p.String())
+	}
+	return Manifest{
+		Name:      bo.PyProject.Project.Name,
+		Version:   bo.PyProject.Project.Version,
+		Source:    bo.source(),
+		Extras:    bo.Extras(),
+		AllExtras: bo.PyProject.Tool.PyBundler.AllExtras,
+		Groups:    groups,
+		Platforms: platforms,
+		Optimize:  bo.PyProject.Tool.PyBundler.Optimize,
+		Python:    python,
+		GoModules: map[string]string{
+			"github.com/kluctl/go-embed-python": module.GoEmbedPython,
+			"github.com/spf13/cobra":            module.Cobra,
+		},
+	}
+}
+
+// Write writes the manifest to MANIFEST_FILE in dir.
+func (m Manifest) Write(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, MANIFEST_FILE), append(b, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("writing manifest: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package bundle_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+const testDependencySelection = `
+[project]
+name = "my-app"
+version = "1.0.0"
+
+[project.optional-dependencies]
+s3 = ["boto3"]
+gcs = ["google-cloud-storage"]
+server = ["uvicorn"]
+
+[project.scripts]
+serve = "app:serve [server]"
+
+[dependency-groups]
+docs = ["mkdocs"]
+
+[tool.pybundler]
+extras = ["s3"]
+`
+
+func TestDependencySelection(t *testing.T) {
+	dir := writePyProject(t, testDependencySelection)
+	output := filepath.Join(t.TempDir(), "out")
+	b, err := bundle.New(dir, output, false, bundle.WithGroups("docs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Extras(); !slices.Equal(got, []string{"s3", "server"}) {
+		t.Fatalf("unexpected extras %v", got)
+	}
+
+	err = b.Manifest().Write(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(output, bundle.MANIFEST_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest bundle.Manifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Name != "my-app" || !slices.Equal(manifest.Extras, []string{"s3", "server"}) || !slices.Equal(manifest.Groups, []string{"docs"}) || manifest.AllExtras {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	b, err = bundle.New(dir, filepath.Join(t.TempDir(), "out"), false, bundle.WithAllExtras())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Extras(); !slices.Equal(got, []string{"gcs", "s3", "server"}) {
+		t.Fatalf("unexpected extras with all extras %v", got)
+	}
+
+	for _, opt := range []bundle.Option{bundle.WithExtras("azure"), bundle.WithGroups("lint")} {
+		if _, err := bundle.New(dir, filepath.Join(t.TempDir(), "out"), false, opt); err == nil {
+			t.Error("expected error for an undeclared extra or group")
+		}
+	}
+}
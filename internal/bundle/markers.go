@@ -0,0 +1,233 @@
+package bundle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// versionMarkers are the environment markers compared as versions.
+var versionMarkers = map[string]bool{
+	"python_version":         true,
+	"python_full_version":    true,
+	"implementation_version": true,
+}
+
+// EvaluateMarker reports whether the environment marker of a requirement,
+// like `sys_platform == 'win32' and python_version < '3.11'`, holds in env.
+// Markers that are not set in env are empty.
+func EvaluateMarker(marker string, env map[string]string) (bool, error) {
+	tokens, err := markerTokens(marker)
+	if err != nil {
+		return false, fmt.Errorf("invalid marker %q: %v", marker, err)
+	}
+	p := &markerParser{tokens: tokens, env: env}
+	ok, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err != nil {
+		return false, fmt.Errorf("invalid marker %q: %v", marker, err)
+	}
+	return ok, nil
+}
+
+// markerTokens splits a marker into parentheses, quoted strings, operators
+// and names. Quoted strings keep their quotes.
+func markerTokens(marker string) ([]string, error) {
+	tokens := make([]string, 0)
+	for i := 0; i < len(marker); {
+		c := marker[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(marker[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, marker[i:i+end+2])
+			i += end + 2
+		case strings.ContainsRune("<>=!~", rune(c)):
+			j := i
+			for j < len(marker) && strings.ContainsRune("<>=!~", rune(marker[j])) {
+				j++
+			}
+			tokens = append(tokens, marker[i:j])
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(marker) && (marker[j] == '_' || marker[j] == '.' || unicode.IsLetter(rune(marker[j])) || unicode.IsDigit(rune(marker[j]))) {
+				j++
+			}
+			tokens = append(tokens, marker[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+type markerParser struct {
+	tokens []string
+	pos    int
+	env    map[string]string
+}
+
+func (p *markerParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *markerParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *markerParser) or() (bool, error) {
+	ok, err := p.and()
+	for err == nil && p.peek() == "or" {
+		p.next()
+		var right bool
+		right, err = p.and()
+		ok = ok || right
+	}
+	return ok, err
+}
+
+func (p *markerParser) and() (bool, error) {
+	ok, err := p.expr()
+	for err == nil && p.peek() == "and" {
+		p.next()
+		var right bool
+		right, err = p.expr()
+		ok = ok && right
+	}
+	return ok, err
+}
+
+func (p *markerParser) expr() (bool, error) {
+	if p.peek() == "(" {
+		p.next()
+		ok, err := p.or()
+		if err != nil {
+			return false, err
+		}
+		if p.next() != ")" {
+			return false, fmt.Errorf("missing closing parenthesis")
+		}
+		return ok, nil
+	}
+	left, leftName, err := p.value()
+	if err != nil {
+		return false, err
+	}
+	op := p.next()
+	if op == "not" {
+		if p.next() != "in" {
+			return false, fmt.Errorf("expected 'in' after 'not'")
+		}
+		op = "not in"
+	}
+	right, rightName, err := p.value()
+	if err != nil {
+		return false, err
+	}
+	return compareMarker(left, op, right, versionMarkers[leftName] || versionMarkers[rightName])
+}
+
+// value returns the next quoted string or the value of the next marker
+// name, together with that name.
+func (p *markerParser) value() (string, string, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return "", "", fmt.Errorf("unexpected end")
+	case t[0] == '\'' || t[0] == '"':
+		return t[1 : len(t)-1], "", nil
+	case t[0] == '_' || unicode.IsLetter(rune(t[0])):
+		return p.env[t], t, nil
+	}
+	return "", "", fmt.Errorf("unexpected %q", t)
+}
+
+func compareMarker(left, op, right string, version bool) (bool, error) {
+	switch op {
+	case "in":
+		return strings.Contains(right, left), nil
+	case "not in":
+		return !strings.Contains(right, left), nil
+	case "===":
+		return left == right, nil
+	}
+	if !version {
+		switch op {
+		case "==":
+			return left == right, nil
+		case "!=":
+			return left != right, nil
+		}
+	}
+	if prefix, ok := strings.CutSuffix(right, ".*"); ok && (op == "==" || op == "!=") {
+		match := left == prefix || strings.HasPrefix(left, prefix+".")
+		return match == (op == "=="), nil
+	}
+	c := compareVersions(left, right)
+	switch op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "~=":
+		i := strings.LastIndex(right, ".")
+		if i < 0 {
+			return false, fmt.Errorf("~= requires a version with at least two parts")
+		}
+		prefix := right[:i]
+		return c >= 0 && (left == prefix || strings.HasPrefix(left, prefix+".")), nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
+
+// compareVersions compares two dotted versions part by part, numerically
+// where both parts are numbers. Missing parts count as zero.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil && xn != yn:
+			if xn < yn {
+				return -1
+			}
+			return 1
+		case (xErr != nil || yErr != nil) && x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
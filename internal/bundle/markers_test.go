@@ -0,0 +1,47 @@
+package bundle_test
+
+import (
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestEvaluateMarker(t *testing.T) {
+	env := map[string]string{
+		"sys_platform":     "linux",
+		"platform_machine": "aarch64",
+		"python_version":   "3.14",
+	}
+	cases := []struct {
+		marker string
+		want   bool
+	}{
+		{"sys_platform == 'linux'", true},
+		{"sys_platform == \"win32\"", false},
+		{"sys_platform != 'win32' and platform_machine == 'aarch64'", true},
+		{"(sys_platform == 'win32' or sys_platform == 'darwin') and python_version >= '3.8'", false},
+		{"python_version < '3.11' or platform_machine in 'x86_64 aarch64'", true},
+		{"python_version >= '3.9'", true},
+		{"python_version < '3.10'", false},
+		{"python_version == '3.*'", true},
+		{"python_version ~= '3.12'", true},
+		{"'linux' not in sys_platform", false},
+		{"extra == 'docs'", false},
+	}
+	for _, c := range cases {
+		got, err := bundle.EvaluateMarker(c.marker, env)
+		if err != nil {
+			t.Errorf("%q: %v", c.marker, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%q: got %v, want %v", c.marker, got, c.want)
+		}
+	}
+
+	for _, marker := range []string{"sys_platform ==", "(sys_platform == 'linux'", "sys_platform == 'linux"} {
+		if _, err := bundle.EvaluateMarker(marker, env); err == nil {
+			t.Errorf("expected error for %q", marker)
+		}
+	}
+}
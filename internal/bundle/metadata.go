@@ -0,0 +1,203 @@
+package bundle
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Readme is the [project] readme, given either as a path or as a table with
+// a file or the inline text.
+type Readme struct {
+	File        string
+	Text        string
+	ContentType string
+}
+
+func (r *Readme) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		r.File = v
+	case map[string]any:
+		var err error
+		r.File, err = tomlString(v, "file")
+		if err != nil {
+			return fmt.Errorf("readme: %v", err)
+		}
+		r.Text, err = tomlString(v, "text")
+		if err != nil {
+			return fmt.Errorf("readme: %v", err)
+		}
+		r.ContentType, err = tomlString(v, "content-type")
+		if err != nil {
+			return fmt.Errorf("readme: %v", err)
+		}
+	default:
+		return fmt.Errorf("readme must be a string or a table, got %T", data)
+	}
+	return nil
+}
+
+// Content returns the text of the readme. Relative files are read relative
+// to the project directory.
+func (r Readme) Content(projectPath string) (string, error) {
+	if r.File == "" {
+		return strings.TrimSpace(r.Text), nil
+	}
+	file := r.File
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(projectPath, file)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("reading readme: %v", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// License is the [project] license, given either as an SPDX expression or
+// as a table with a file or the license text.
+type License struct {
+	Expression string
+	File       string
+	Text       string
+}
+
+func (l *License) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		l.Expression = v
+	case map[string]any:
+		var err error
+		l.File, err = tomlString(v, "file")
+		if err != nil {
+			return fmt.Errorf("license: %v", err)
+		}
+		l.Text, err = tomlString(v, "text")
+		if err != nil {
+			return fmt.Errorf("license: %v", err)
+		}
+	default:
+		return fmt.Errorf("license must be a string or a table, got %T", data)
+	}
+	return nil
+}
+
+// String returns a one line description of the license.
+func (l License) String() string {
+	switch {
+	case l.Expression != "":
+		return l.Expression
+	case l.Text != "":
+		first, _, _ := strings.Cut(strings.TrimSpace(l.Text), "\n")
+		return strings.TrimSpace(first)
+	case l.File != "":
+		return "see " + l.File
+	}
+	return ""
+}
+
+type Author struct {
+	Name  string `toml:"name"`
+	Email string `toml:"email"`
+}
+
+func (a Author) String() string {
+	switch {
+	case a.Name != "" && a.Email != "":
+		return fmt.Sprintf("%s <%s>", a.Name, a.Email)
+	case a.Email != "":
+		return a.Email
+	}
+	return a.Name
+}
+
+// URL returns the first project url with one of the given labels. Labels
+// are compared in the normalized form described by the well-known project
+// urls specification, so "Bug Tracker" matches "bugtracker". When several
+// urls match a label, the first name in sorted order is used.
+func (p *PyProject) URL(labels ...string) string {
+	names := slices.Sorted(maps.Keys(p.Project.URLs))
+	for _, label := range labels {
+		for _, name := range names {
+			if normalizeLabel(name) == label {
+				return p.Project.URLs[name]
+			}
+		}
+	}
+	return ""
+}
+
+// HelpFooter returns the text appended to the help of the root command.
+func (p *PyProject) HelpFooter() string {
+	lines := make([]string, 0)
+	fields := []struct{ title, value string }{
+		{"Homepage", p.URL("homepage", "home")},
+		{"Documentation", p.URL("documentation", "docs")},
+		{"Issues", p.URL("issues", "issue", "bugs", "bugtracker", "issuetracker", "tracker")},
+		{"License", p.Project.License.String()},
+	}
+	authors := make([]string, 0)
+	for _, a := range p.Project.Authors {
+		if s := a.String(); s != "" {
+			authors = append(authors, s)
+		}
+	}
+	if len(authors) > 0 {
+		fields = append(fields, struct{ title, value string }{"Authors", strings.Join(authors, ", ")})
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", f.title, f.value))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// SetProjectMetadata describes the root command with the version and
+// description of the project. The readme is only used as the long
+// description when help-readme is set. Descriptions already set on c are
+// kept.
+func (c *Command) SetProjectMetadata(p *PyProject, projectPath string) {
+	c.Version = p.Project.Version
+	c.Footer = p.HelpFooter()
+	if c.Short == "" {
+		c.Short = strings.TrimSpace(p.Project.Description)
+	}
+	if c.Long == "" && p.Tool.PyBundler.HelpReadme {
+		readme, err := p.Project.Readme.Content(projectPath)
+		if err != nil {
+			slog.Warn("Could not use readme as description", "error", err)
+		}
+		c.Long = readme
+	}
+}
+
+func normalizeLabel(label string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, label)
+}
+
+func tomlString(table map[string]any, key string) (string, error) {
+	v, ok := table[key]
+	if !ok {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string, got %T", key, v)
+	}
+	return s, nil
+}
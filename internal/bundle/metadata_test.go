@@ -0,0 +1,96 @@
+package bundle_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func writePyProject(t *testing.T, src string) string {
+	t.Helper()
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestProjectMetadata(t *testing.T) {
+	dir := writePyProject(t, `
+[project]
+name = "my-app"
+version = "1.2.3"
+description = "Does things"
+readme = "README.md"
+license = "MIT"
+authors = [{ name = "Jane Doe", email = "jane@example.com" }, { name = "John" }]
+
+[project.urls]
+Homepage = "https://example.com"
+"Bug Tracker" = "https://example.com/issues"
+bugtracker = "https://example.com/other-issues"
+
+[project.scripts]
+my-app = "app:main"
+`)
+	err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# My app\n\nLonger text.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pyproject, err := bundle.NewPyProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := bundle.NewRootCommand("my-app")
+	root.SetProjectMetadata(pyproject, dir)
+	if root.Version != "1.2.3" || root.Short != "Does things" || root.Long != "" {
+		t.Fatalf("unexpected metadata %q, %q, %q", root.Version, root.Short, root.Long)
+	}
+	for _, want := range []string{
+		"Homepage: https://example.com\n",
+		"Issues: https://example.com/issues\n",
+		"License: MIT\n",
+		"Authors: Jane Doe <jane@example.com>, John\n",
+	} {
+		if !strings.Contains(root.Footer, want) {
+			t.Errorf("expected %q in footer %q", want, root.Footer)
+		}
+	}
+
+	pyproject.Tool.PyBundler.HelpReadme = true
+	root = bundle.NewRootCommand("my-app")
+	root.SetProjectMetadata(pyproject, dir)
+	if root.Long != "# My app\n\nLonger text." {
+		t.Fatalf("unexpected long description with help-readme %q", root.Long)
+	}
+}
+
+func TestProjectMetadataTables(t *testing.T) {
+	dir := writePyProject(t, `
+[project]
+name = "my-app"
+version = "1.2.3"
+readme = { text = "Inline readme", content-type = "text/plain" }
+license = { text = "Apache License 2.0\n\nFull text" }
+`)
+	pyproject, err := bundle.NewPyProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readme, err := pyproject.Project.Readme.Content(dir)
+	if err != nil || readme != "Inline readme" {
+		t.Fatalf("got %q, %v", readme, err)
+	}
+	if license := pyproject.Project.License.String(); license != "Apache License 2.0" {
+		t.Fatalf("got license %q", license)
+	}
+
+	dir = writePyProject(t, "[project]\nname = \"my-app\"\nversion = \"1.2.3\"\nreadme = 1\n")
+	if _, err := bundle.NewPyProject(dir); err == nil {
+		t.Fatal("expected error for invalid readme")
+	}
+}
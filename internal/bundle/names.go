@@ -0,0 +1,191 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/mod/module"
+)
+
+// GoIdentifier turns s into a valid Go identifier by replacing every rune
+// that may not appear in an identifier with an underscore. Identifiers that
+// would start with a digit or collide with a keyword get a leading
+// underscore.
+func GoIdentifier(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	ident := b.String()
+	if ident == "" {
+		return "_"
+	}
+	if first := []rune(ident)[0]; unicode.IsDigit(first) || token.IsKeyword(ident) {
+		ident = "_" + ident
+	}
+	return ident
+}
+
+// GoExportedIdentifier returns a valid, exported Go identifier for s.
+func GoExportedIdentifier(s string) string {
+	ident := GoIdentifier(ToPascalCase(s))
+	if !token.IsExported(ident) {
+		ident = "Cmd" + ident
+	}
+	return ident
+}
+
+// GoPackageName returns a valid Go package name for s. Package names are
+// also used as directory names in import paths, so they are restricted to
+// lower case ASCII letters, digits and underscores; other letters are
+// spelled out by their code point.
+func GoPackageName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			fmt.Fprintf(&b, "u%04x", r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	name := strings.Trim(b.String(), "_")
+	if name == "" || unicode.IsDigit(rune(name[0])) || token.IsKeyword(name) {
+		name = "pkg_" + name
+	}
+	return name
+}
+
+// reservedPackages are package names used by the templates, which
+// generated command packages must not shadow.
+var reservedPackages = map[string]bool{
+	"cmd":        true,
+	"cobra":      true,
+	"data":       true,
+	"embed_util": true,
+	"exec":       true,
+	"filepath":   true,
+	"generate":   true,
+	"internal":   true,
+	"log":        true,
+	"main":       true,
+	"os":         true,
+	"python":     true,
+	"runner":     true,
+}
+
+// GoModulePath derives the module path of the generated Go project from
+// the Python project name.
+func GoModulePath(projectName string) (string, error) {
+	if !namePattern.MatchString(projectName) {
+		return "", fmt.Errorf("project name %q is not a valid Python project name", projectName)
+	}
+	p := NormalizeName(projectName)
+	if err := module.CheckImportPath(p); err != nil {
+		return "", fmt.Errorf("project name %q cannot be used as a Go module path: %v", projectName, err)
+	}
+	return p, nil
+}
+
+// ImportPath joins elems into an import path and validates the result.
+func ImportPath(elems ...string) (string, error) {
+	p := path.Join(elems...)
+	if err := module.CheckImportPath(p); err != nil {
+		return "", fmt.Errorf("invalid import path %q: %v", p, err)
+	}
+	return p, nil
+}
+
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// PyProjectKey returns the dotted TOML key for the given path, quoting the
+// parts that are not bare keys.
+func PyProjectKey(parts ...string) string {
+	quoted := make([]string, len(parts))
+	for i, p := range parts {
+		if bareKeyPattern.MatchString(p) {
+			quoted[i] = p
+		} else {
+			quoted[i] = fmt.Sprintf("%q", p)
+		}
+	}
+	return strings.Join(quoted, ".")
+}
+
+// ResolveNames checks that scripts and gui scripts have distinct names and
+// assigns every command a Go package name that is unique among its
+// siblings.
+func (sc *CommandCollection) ResolveNames() error {
+	// Scripts and gui scripts are both installed as executables, so they
+	// share a namespace even though they are rendered in separate groups.
+	errs := make([]error, 0)
+	scripts := map[string]*Command{}
+	sc.Scripts.Walk(func(c *Command) {
+		if c.Entry != nil {
+			scripts[strings.Join(c.Path(), " ")] = c
+		}
+	})
+	sc.GuiScripts.Walk(func(c *Command) {
+		name := strings.Join(c.Path(), " ")
+		if other, ok := scripts[name]; ok && c.Entry != nil {
+			errs = append(errs, fmt.Errorf("command name '%s' is defined by both %s and %s", name, other.Origin, c.Origin))
+		}
+	})
+	for _, group := range sc.Trees() {
+		group.Walk(func(c *Command) {
+			names := map[string]*Command{}
+			for _, child := range c.Children {
+				for _, name := range append([]string{child.Name}, child.Aliases...) {
+					if other, ok := names[name]; ok && other != child {
+						errs = append(errs, fmt.Errorf("command name '%s' is used by both %s and %s", strings.Join(append(c.Path(), name), " "), other.Origin, child.Origin))
+					}
+					names[name] = child
+				}
+			}
+		})
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, group := range sc.Trees() {
+		group.Package = GoPackageName(group.Name)
+		group.VarName = GoExportedIdentifier(group.Name + "-cmd")
+		group.Walk(func(c *Command) {
+			assignPackageNames(c.Children)
+		})
+	}
+	return nil
+}
+
+// assignPackageNames gives each command a package name that is unique among
+// cmds. Names that would shadow a predeclared identifier or a package
+// imported by the templates get a suffix.
+func assignPackageNames(cmds []*Command) {
+	used := map[string]bool{}
+	for _, c := range cmds {
+		base := GoPackageName(c.Name)
+		if types.Universe.Lookup(base) != nil || reservedPackages[base] {
+			base += "_cmd"
+		}
+		name := base
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		used[name] = true
+		c.Package = name
+		c.VarName = GoExportedIdentifier(c.Name + "-cmd")
+	}
+}
@@ -0,0 +1,71 @@
+package bundle_test
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestResolveNames(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{
+		Name: "my-app",
+		Scripts: map[string]string{
+			"2fa":     "app:main",
+			"type":    "app:main",
+			"go":      "app:main",
+			"my-tool": "app:main",
+			"my.tool": "app:main",
+			"café":    "app:main",
+			"execute": "app:main",
+			"string":  "app:main",
+		},
+	}}
+	pyproject.Tool.PyBundler.CommandSeparator = new(string)
+	sc, err := bundle.NewCommandCollection(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages := map[string]bool{}
+	for _, c := range sc.Scripts.Children {
+		if !token.IsIdentifier(c.Package) || c.Package != strings.ToLower(c.Package) {
+			t.Errorf("%s: invalid package name %q", c.Name, c.Package)
+		}
+		if !token.IsIdentifier(c.VarName) || !token.IsExported(c.VarName) {
+			t.Errorf("%s: invalid variable name %q", c.Name, c.VarName)
+		}
+		if _, err := bundle.ImportPath("my-app", c.Dir()); err != nil {
+			t.Errorf("%s: %v", c.Name, err)
+		}
+		if packages[c.Package] {
+			t.Errorf("%s: duplicate package name %q", c.Name, c.Package)
+		}
+		packages[c.Package] = true
+	}
+}
+
+func TestResolveNamesReportsCollisions(t *testing.T) {
+	cases := map[string]bundle.ProjectSection{
+		`command name 'foo-bar' is defined by both project.scripts.foo-bar and project.scripts.foo_bar`: {
+			Scripts: map[string]string{"foo-bar": "app:main", "foo_bar": "app:main"},
+		},
+		`command name 'tool' is defined by both project.scripts.tool and project.gui-scripts.tool`: {
+			Scripts:    map[string]string{"tool": "app:main"},
+			GuiScripts: map[string]string{"tool": "app:gui"},
+		},
+		`command name 'a-b' is defined by both project.entry-points.grp.a-b and project.entry-points.grp.a_b`: {
+			EntryPoints: map[string]map[string]string{"grp": {"a_b": "app:main", "a-b": "app:main"}},
+		},
+		`command name 'db migrate' is defined by both project.scripts."db migrate" and project.scripts."db.migrate"`: {
+			Scripts: map[string]string{"db.migrate": "app:main", "db migrate": "app:main"},
+		},
+	}
+	for want, project := range cases {
+		project.Name = "my-app"
+		_, err := bundle.NewCommandCollection(bundle.PyProject{Project: project})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q, got %v", want, err)
+		}
+	}
+}
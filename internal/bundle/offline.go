@@ -0,0 +1,405 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Cache holds the artifacts needed to bundle without network access. The
+// Python distributions are part of the go-embed-python module, so they are
+// kept with the Go modules. Offline bundles use nothing else.
+type Cache struct {
+	Dir     string
+	Offline bool
+}
+
+// DefaultCacheDir returns the cache directory used when none is given.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding cache directory: %v", err)
+	}
+	return filepath.Join(dir, "pybundler"), nil
+}
+
+// GoModCache returns the Go module cache.
+func (c Cache) GoModCache() string {
+	return filepath.Join(c.Dir, "go")
+}
+
+// UVCache returns the uv cache, holding the packages uv needs to build and
+// introspect the project.
+func (c Cache) UVCache() string {
+	return filepath.Join(c.Dir, "uv")
+}
+
+// Wheelhouse returns the directory with the wheels of the dependencies for
+// every platform.
+func (c Cache) Wheelhouse() string {
+	return filepath.Join(c.Dir, "wheels")
+}
+
+// Setenv makes every go, uv and pip command run by the bundler use the
+// cache. Offline, nothing is downloaded. The checksum database is not
+// consulted either, as the modules were verified when they were prefetched.
+func (c Cache) Setenv() error {
+	env := map[string]string{
+		"GOMODCACHE":   c.GoModCache(),
+		"UV_CACHE_DIR": c.UVCache(),
+		"GOFLAGS":      strings.TrimSpace(os.Getenv("GOFLAGS") + " -modcacherw"),
+	}
+	if c.Offline {
+		env["GOFLAGS"] += " -mod=mod"
+		env["GOPROXY"] = "off"
+		env["GOSUMDB"] = "off"
+		env["GOTOOLCHAIN"] = "local"
+		env["UV_OFFLINE"] = "1"
+	}
+	for k, v := range env {
+		err := os.Setenv(k, v)
+		if err != nil {
+			return fmt.Errorf("setting %s: %v", k, err)
+		}
+	}
+	return nil
+}
+
+// requirements returns the options of a requirements file that make pip
+// install from the wheelhouse only.
+func (c Cache) requirements() []string {
+	return []string{"--no-index", "--find-links " + c.Wheelhouse()}
+}
+
+// MissingGoModules returns the Go modules of module that are not in the
+// module cache, as path@version.
+func MissingGoModules(module GoModule, verbose bool) ([]string, error) {
+	dir, err := os.MkdirTemp("", "pybundler-go-")
+	if err != nil {
+		return nil, fmt.Errorf("creating Go module directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	err = module.WriteDependencies(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type goModule struct {
+		Path    string
+		Version string
+		Error   *struct{ Err string }
+	}
+	out, err := RunCmd(dir, verbose, "go", "list", "-m", "-e", "-json", "all")
+	if err != nil {
+		return nil, fmt.Errorf("listing Go modules: %v", err)
+	}
+	missing := make([]string, 0)
+	modules := make([]goModule, 0)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var m goModule
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading Go modules: %v", err)
+		}
+		if m.Error != nil {
+			missing = append(missing, m.Path+"@"+m.Version)
+		}
+		modules = append(modules, m)
+	}
+
+	out, err = RunCmd(dir, verbose, "go", "list", "-e", "-deps", "-json=ImportPath,Error", ".")
+	if err != nil {
+		return nil, fmt.Errorf("listing Go packages: %v", err)
+	}
+	dec = json.NewDecoder(bytes.NewReader(out))
+	for {
+		var pkg struct {
+			ImportPath string
+			Error      *struct{ Err string }
+		}
+		err := dec.Decode(&pkg)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading Go packages: %v", err)
+		}
+		if pkg.Error == nil {
+			continue
+		}
+		// The module of a package that cannot be loaded is the one with the
+		// longest path that contains it.
+		var module *goModule
+		for i, m := range modules {
+			if (pkg.ImportPath == m.Path || strings.HasPrefix(pkg.ImportPath, m.Path+"/")) && (module == nil || len(m.Path) > len(module.Path)) {
+				module = &modules[i]
+			}
+		}
+		if module == nil || module.Version == "" {
+			return nil, fmt.Errorf("loading Go package %s: %s", pkg.ImportPath, pkg.Error.Err)
+		}
+		if id := module.Path + "@" + module.Version; !slices.Contains(missing, id) {
+			missing = append(missing, id)
+		}
+	}
+	slices.Sort(missing)
+	return missing, nil
+}
+
+// Requirement is a pinned requirement exported by uv.
+type Requirement struct {
+	Name    string
+	Version string
+	Marker  string
+	Hashes  []string
+
+	// Path is the local wheel the requirement is installed from, if any.
+	// It takes the place of the name and version in a requirements file.
+	Path string
+}
+
+// ParseRequirement parses a logical line of a requirements file, like
+// `click==8.1.8 ; sys_platform == 'linux' --hash=sha256:...`. Only pinned
+// requirements are parsed.
+func ParseRequirement(line string) (*Requirement, bool) {
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+		return nil, false
+	}
+	spec, _, _ := strings.Cut(line, " --hash=")
+	spec, marker, _ := strings.Cut(spec, ";")
+	name, version, ok := strings.Cut(spec, "==")
+	if !ok {
+		return nil, false
+	}
+	name, _, _ = strings.Cut(name, "[")
+	req := &Requirement{
+		Name:    NormalizeName(strings.TrimSpace(name)),
+		Version: strings.TrimSpace(version),
+		Marker:  strings.TrimSpace(marker),
+	}
+	for _, field := range strings.Fields(line) {
+		if strings.HasPrefix(field, "--hash=") {
+			req.Hashes = append(req.Hashes, field)
+		}
+	}
+	return req, true
+}
+
+// String returns the requirement without its marker.
+func (r *Requirement) String() string {
+	return strings.Join(append([]string{r.Name + "==" + r.Version}, r.Hashes...), " ")
+}
+
+// Line returns the requirement as a logical line of a requirements file.
+func (r *Requirement) Line() string {
+	spec := r.Name + "==" + r.Version
+	if r.Path != "" {
+		spec = r.Path
+	}
+	if r.Marker != "" {
+		spec += " ; " + r.Marker
+	}
+	return strings.Join(append([]string{spec}, r.Hashes...), " ")
+}
+
+// WithMarker returns a copy of the requirement that is only installed where
+// both its marker and marker hold.
+func (r *Requirement) WithMarker(marker string) *Requirement {
+	req := *r
+	req.Hashes = slices.Clone(r.Hashes)
+	req.Marker = marker
+	if r.Marker != "" {
+		req.Marker = "(" + r.Marker + ") and (" + marker + ")"
+	}
+	return &req
+}
+
+// InstalledOn reports whether the requirement is installed on the platform.
+// Requirements with markers that cannot be evaluated are.
+func (r *Requirement) InstalledOn(p Platform, pythonVersion string) bool {
+	if r.Marker == "" {
+		return true
+	}
+	ok, err := EvaluateMarker(r.Marker, p.MarkerEnvironment(pythonVersion))
+	if err != nil {
+		slog.Warn("Assuming the requirement is installed", "requirement", r.Name, "platform", p.String(), "error", err)
+		return true
+	}
+	return ok
+}
+
+// MissingWheels returns the pinned requirements in pkgReqs that lack a
+// wheel in dir for a platform they are installed on, like
+// `click==8.1.8 (linux-arm64, windows-amd64)`. Wheels must match one of the
+// hashes of a requirement.
+func MissingWheels(pkgReqs []byte, dir string, platforms []Platform, pythonVersion string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading wheelhouse %s: %v", dir, err)
+	}
+	missing := make([]string, 0)
+	for _, line := range RequirementLines(pkgReqs) {
+		req, ok := ParseRequirement(line)
+		if !ok {
+			continue
+		}
+		wheels := make([]*WheelFile, 0)
+		for _, entry := range entries {
+			wheel, err := ParseWheelFilename(entry.Name())
+			if err != nil || wheel.Name != req.Name || wheel.Version != req.Version {
+				continue
+			}
+			if len(req.Hashes) > 0 {
+				hash, err := FileHash(filepath.Join(dir, entry.Name()))
+				if err != nil {
+					return nil, err
+				}
+				if !slices.Contains(req.Hashes, hash) {
+					continue
+				}
+			}
+			wheels = append(wheels, wheel)
+		}
+		lacking := make([]string, 0)
+		for _, p := range platforms {
+			if !req.InstalledOn(p, pythonVersion) {
+				continue
+			}
+			if !slices.ContainsFunc(wheels, func(w *WheelFile) bool { return p.SupportsWheel(w, pythonVersion) }) {
+				lacking = append(lacking, p.String())
+			}
+		}
+		if len(lacking) > 0 {
+			missing = append(missing, fmt.Sprintf("%s==%s (%s)", req.Name, req.Version, strings.Join(lacking, ", ")))
+		}
+	}
+	return missing, nil
+}
+
+// checkOffline returns an error that lists every artifact missing from the
+// cache to bundle offline.
+func (bo *BundleOptions) checkOffline(verbose bool) error {
+	slog.Info("Checking the offline cache", "cache", bo.Cache.Dir)
+	missing := make([]string, 0)
+	module := bo.GoModule()
+	packaging, err := bo.Packaging()
+	if err != nil {
+		return err
+	}
+	pkgReqs, _, err := bo.exportRequirements(verbose)
+	if err != nil {
+		missing = append(missing, fmt.Sprintf("uv cache: exporting requirements failed: %v", err))
+	} else {
+		wheels, err := MissingWheels(pkgReqs, bo.Cache.Wheelhouse(), packaging.Platforms, packaging.PythonVersion)
+		if err != nil {
+			return err
+		}
+		unbuilt := make([]string, 0)
+		for _, w := range wheels {
+			name, _, _ := strings.Cut(w, "==")
+			if slices.ContainsFunc(bo.PyProject.Tool.PyBundler.BuildSdists, func(allowed string) bool { return NormalizeName(allowed) == name }) {
+				unbuilt = append(unbuilt, w)
+			}
+			missing = append(missing, "wheel "+w)
+		}
+		if len(unbuilt) > 0 {
+			return fmt.Errorf("wheels are not built from sdists with --offline, bundle without --offline to build them:\n  %s", strings.Join(unbuilt, "\n  "))
+		}
+	}
+	modules, err := MissingGoModules(module, verbose)
+	if err != nil {
+		return err
+	}
+	for _, m := range modules {
+		missing = append(missing, "Go module "+m)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the cache %s lacks artifacts needed to bundle offline, run `pybundler prefetch` with the same arguments on a connected machine and copy the cache:\n  %s", bo.Cache.Dir, strings.Join(missing, "\n  "))
+	}
+	return nil
+}
+
+// Prefetch downloads everything needed to bundle offline into the cache:
+// the Go modules, which include the Python distributions, the packages uv
+// needs to build, export and introspect the project, and the wheels of the
+// dependencies for the platforms of the bundle. The cache environment must
+// be set.
+func (bo *BundleOptions) Prefetch(verbose bool) error {
+	slog.Info("Prefetching Go modules", "cache", bo.Cache.GoModCache())
+	dir, err := os.MkdirTemp("", "pybundler-go-")
+	if err != nil {
+		return fmt.Errorf("creating Go module directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	module := bo.GoModule()
+	err = module.WriteDependencies(dir)
+	if err != nil {
+		return err
+	}
+	_, err = bo.run(dir, verbose, "go", "mod", "tidy")
+	if err != nil {
+		return fmt.Errorf("downloading Go modules: %v", err)
+	}
+
+	slog.Info("Prefetching packages for uv", "cache", bo.Cache.UVCache())
+	if bo.Script == "" {
+		_, err = bo.run(bo.Path, verbose, append(bo.python(), "-c", "")...)
+		if err != nil {
+			return fmt.Errorf("installing the project: %v", err)
+		}
+	}
+	err = bo.buildWheel(verbose)
+	if err != nil {
+		return err
+	}
+	pkgReqs, local, err := bo.exportRequirements(verbose)
+	if err != nil {
+		return err
+	}
+	_, err = bo.buildLocalWheels(local, verbose)
+	if err != nil {
+		return err
+	}
+
+	packaging, err := bo.Packaging()
+	if err != nil {
+		return err
+	}
+	pythonVersion := packaging.PythonVersion
+	for _, p := range packaging.Platforms {
+		slog.Info("Prefetching wheels", "platform", p.String(), "wheelhouse", bo.Cache.Wheelhouse())
+		reqs := bo.PyProject.Tool.PyBundler.Requirements()
+		for _, line := range RequirementLines(pkgReqs) {
+			if req, ok := ParseRequirement(line); ok && req.InstalledOn(p, pythonVersion) {
+				reqs = append(reqs, req.String())
+			}
+		}
+		input := filepath.Join(dir, "requirements-"+p.String()+".txt")
+		err := os.WriteFile(input, []byte(strings.Join(reqs, "\n")+"\n"), 0644)
+		if err != nil {
+			return fmt.Errorf("writing requirements: %v", err)
+		}
+		major, minor, _ := pythonMinor(pythonVersion)
+		args := []string{"uv", "tool", "run", "pip", "download", "-r", input, "-d", bo.Cache.Wheelhouse(),
+			"--no-deps", "--only-binary=:all:", "--implementation", "cp", "--python-version", fmt.Sprintf("%d.%d", major, minor)}
+		for _, tag := range p.Tags {
+			args = append(args, "--platform", tag)
+		}
+		_, err = bo.run(bo.Path, verbose, args...)
+		if err != nil {
+			return fmt.Errorf("downloading wheels for %s: %v", p, err)
+		}
+	}
+	slog.Info("Prefetched artifacts for offline bundling", "cache", bo.Cache.Dir)
+	return nil
+}
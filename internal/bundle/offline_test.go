@@ -0,0 +1,77 @@
+package bundle_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestParseRequirement(t *testing.T) {
+	req, ok := bundle.ParseRequirement("Colorama[extra]==0.4.6 ; sys_platform == 'win32' --hash=sha256:aa --hash=sha256:bb")
+	if !ok || req.Name != "colorama" || req.Version != "0.4.6" || req.Marker != "sys_platform == 'win32'" || len(req.Hashes) != 2 {
+		t.Fatalf("unexpected requirement %+v", req)
+	}
+	if req.String() != "colorama==0.4.6 --hash=sha256:aa --hash=sha256:bb" {
+		t.Fatalf("unexpected requirement line %q", req.String())
+	}
+	for _, line := range []string{"# via click", "-e ./packages/lib", "lib @ file:///tmp/lib-1.0-py3-none-any.whl"} {
+		if _, ok := bundle.ParseRequirement(line); ok {
+			t.Errorf("expected %q not to be a pinned requirement", line)
+		}
+	}
+}
+
+func TestMissingWheels(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "click-8.1.8-py3-none-any.whl"), []byte("click"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "pyyaml-6.0.2-cp314-cp314-manylinux_2_17_x86_64.whl"), []byte("tampered"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	click, err := bundle.FileHash(filepath.Join(dir, "click-8.1.8-py3-none-any.whl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := "click==8.1.8 \\\n    " + click + "\n" +
+		"colorama==0.4.6 ; sys_platform == 'win32'\n" +
+		"pyyaml==6.0.2 ; sys_platform == 'linux' \\\n    --hash=sha256:00\n"
+	missing, err := bundle.MissingWheels([]byte(reqs), dir, bundle.PLATFORMS, "3.14.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"colorama==0.4.6 (windows-amd64)", "pyyaml==6.0.2 (linux-amd64, linux-arm64)"}
+	if !slices.Equal(missing, want) {
+		t.Fatalf("unexpected missing wheels %q", missing)
+	}
+}
+
+func TestMissingGoModules(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOFLAGS", "-mod=mod -modcacherw")
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOSUMDB", "off")
+	module := bundle.GoModule{
+		GoVersion:     bundle.GO_VERSION,
+		GoEmbedPython: bundle.GO_EMBED_PYTHON_VERSION,
+		Cobra:         bundle.COBRA_VERSION,
+	}
+	missing, err := bundle.MissingGoModules(module, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"github.com/kluctl/go-embed-python@" + bundle.GO_EMBED_PYTHON_VERSION, "github.com/spf13/cobra@" + bundle.COBRA_VERSION, "github.com/spf13/pflag@v1.0.6"} {
+		if !slices.Contains(missing, want) {
+			t.Fatalf("expected %s in missing modules %q", want, missing)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package bundle
+
+import "fmt"
+
+// Packaging is what the generate step of the generated project installs the
+// dependencies of the bundle for. The step runs `uv pip install --target`
+// for every platform in parallel, so the platforms share the uv cache and
+// every wheel is only downloaded once. The bytecode of the installed
+// modules is compiled with the optimization level Optimize.
+type Packaging struct {
+	PythonVersion string
+	Platforms     []Platform
+	Optimize      int
+}
+
+// WithOptimize sets the optimization level the bytecode of the bundle is
+// compiled with, from 0 to 2.
+func WithOptimize(level int) Option {
+	return func(p *PyProject) {
+		p.Tool.PyBundler.Optimize = level
+	}
+}
+
+// Packaging returns the packaging of the bundle for its platforms with the
+// Python version embedded by go-embed-python.
+func (bo *BundleOptions) Packaging() (Packaging, error) {
+	pythonVersion, err := PythonVersion(bo.GoModule().GoEmbedPython)
+	if err != nil {
+		return Packaging{}, err
+	}
+	platforms, err := bo.Platforms()
+	if err != nil {
+		return Packaging{}, err
+	}
+	optimize := bo.PyProject.Tool.PyBundler.Optimize
+	if optimize < 0 || optimize > 2 {
+		return Packaging{}, fmt.Errorf("invalid optimization level %d, expected 0, 1 or 2", optimize)
+	}
+	return Packaging{PythonVersion: pythonVersion, Platforms: platforms, Optimize: optimize}, nil
+}
@@ -0,0 +1,81 @@
+package bundle_test
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestPackaging(t *testing.T) {
+	dir := writePyProject(t, testDependencySelection)
+	b, err := bundle.New(dir, filepath.Join(t.TempDir(), "out"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packaging, err := b.Packaging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packaging.PythonVersion != "3.14.6" || len(packaging.Platforms) != len(bundle.PLATFORMS) {
+		t.Fatalf("unexpected packaging %+v", packaging)
+	}
+	src, err := bundle.RenderTemplate("generate.go.tmpl", packaging)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.ParseFile(token.NewFileSet(), "main.go", src, 0)
+	if err != nil {
+		t.Fatalf("rendered source does not parse: %v\n%s", err, src)
+	}
+	for _, p := range bundle.PLATFORMS {
+		want := "{" + strconv.Quote(p.GOOS) + ", " + strconv.Quote(p.GOARCH) + ", " + strconv.Quote(p.UV)
+		if !strings.Contains(src, want) {
+			t.Errorf("expected platform %s in generate.go:\n%s", p, src)
+		}
+	}
+	if !strings.Contains(src, `"MACOSX_DEPLOYMENT_TARGET=12.0"`) {
+		t.Errorf("expected the macOS deployment target in generate.go:\n%s", src)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	dir := writePyProject(t, testDependencySelection)
+	b, err := bundle.New(dir, filepath.Join(t.TempDir(), "out"), false, bundle.WithOptimize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	packaging, err := b.Packaging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := bundle.RenderTemplate("generate.go.tmpl", packaging)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(src, "optimize         = 2\n") || !strings.Contains(src, `"unchecked-hash"`) {
+		t.Fatalf("expected bytecode compiled with level 2 in generate.go:\n%s", src)
+	}
+
+	root := &bundle.Command{AppName: "my-app", Optimize: packaging.Optimize}
+	src, err = bundle.RenderTemplate("runner.go.tmpl", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(src, `"PYTHONOPTIMIZE=2"`) {
+		t.Fatalf("expected the runner to run with level 2:\n%s", src)
+	}
+	_, err = parser.ParseFile(token.NewFileSet(), "runner.go", src, 0)
+	if err != nil {
+		t.Fatalf("rendered source does not parse: %v\n%s", err, src)
+	}
+
+	_, err = bundle.New(dir, filepath.Join(t.TempDir(), "out"), false, bundle.WithOptimize(3))
+	if err == nil {
+		t.Fatal("expected error for an invalid optimization level")
+	}
+}
@@ -0,0 +1,304 @@
+package bundle
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Platform is a target platform of the bundle.
+type Platform struct {
+	GOOS   string
+	GOARCH string
+
+	// UV is the --python-platform uv installs the dependencies for.
+	UV string
+
+	// Tags are the platform tags of the wheels installed for the platform.
+	Tags []string
+}
+
+// PLATFORMS are the platforms the dependencies of a bundle are packaged for,
+// as known to go-embed-python.
+var PLATFORMS = []Platform{
+	{"darwin", "amd64", "x86_64-apple-darwin", []string{"macosx_11_0_x86_64", "macosx_12_0_x86_64"}},
+	{"darwin", "arm64", "aarch64-apple-darwin", []string{"macosx_11_0_arm64", "macosx_12_0_arm64"}},
+	{"linux", "amd64", "x86_64-manylinux_2_28", []string{"manylinux_2_17_x86_64", "manylinux_2_28_x86_64", "manylinux2014_x86_64"}},
+	{"linux", "arm64", "aarch64-manylinux_2_28", []string{"manylinux_2_17_aarch64", "manylinux_2_28_aarch64", "manylinux2014_aarch64"}},
+	{"windows", "amd64", "x86_64-pc-windows-msvc", []string{"win_amd64"}},
+}
+
+func (p Platform) String() string {
+	return p.GOOS + "-" + p.GOARCH
+}
+
+// WithPlatforms selects the platforms the bundle is packaged for, like
+// linux-amd64.
+func WithPlatforms(platforms ...string) Option {
+	return func(p *PyProject) {
+		p.Tool.PyBundler.Platforms = append(p.Tool.PyBundler.Platforms, platforms...)
+	}
+}
+
+// SelectPlatforms returns the platforms in PLATFORMS with the given names,
+// in the order of PLATFORMS, or all of them when no names are given.
+func SelectPlatforms(names []string) ([]Platform, error) {
+	if len(names) == 0 {
+		return PLATFORMS, nil
+	}
+	known := make([]string, 0, len(PLATFORMS))
+	for _, p := range PLATFORMS {
+		known = append(known, p.String())
+	}
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("unknown platform %s, expected one of %s", name, strings.Join(known, ", "))
+		}
+	}
+	platforms := make([]Platform, 0, len(names))
+	for _, p := range PLATFORMS {
+		if slices.Contains(names, p.String()) {
+			platforms = append(platforms, p)
+		}
+	}
+	return platforms, nil
+}
+
+// HostPlatform returns the platform in PLATFORMS pybundler runs on, if any.
+func HostPlatform() (Platform, bool) {
+	i := slices.IndexFunc(PLATFORMS, func(p Platform) bool { return p.GOOS == runtime.GOOS && p.GOARCH == runtime.GOARCH })
+	if i < 0 {
+		return Platform{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}, false
+	}
+	return PLATFORMS[i], true
+}
+
+// Platforms returns the platforms the bundle is packaged for.
+func (bo *BundleOptions) Platforms() ([]Platform, error) {
+	return SelectPlatforms(bo.PyProject.Tool.PyBundler.Platforms)
+}
+
+// Environ returns the environment uv needs to install the dependencies for
+// the platform. uv assumes a macOS version unless MACOSX_DEPLOYMENT_TARGET
+// selects the newest one in Tags.
+func (p Platform) Environ() []string {
+	var env []string
+	for _, tag := range p.Tags {
+		version, _, ok := platformVersion(tag)
+		if ok && platformFamily(tag) == "macosx" {
+			env = []string{fmt.Sprintf("MACOSX_DEPLOYMENT_TARGET=%d.%d", version[0], version[1])}
+		}
+	}
+	return env
+}
+
+// PythonVersion returns the version of the Python distribution embedded by
+// a go-embed-python version like v0.0.0-3.14.6-20260610-1.
+func PythonVersion(goEmbedPythonVersion string) (string, error) {
+	parts := strings.Split(goEmbedPythonVersion, "-")
+	if len(parts) < 2 || strings.Count(parts[1], ".") != 2 {
+		return "", fmt.Errorf("go-embed-python version %s does not contain a Python version", goEmbedPythonVersion)
+	}
+	return parts[1], nil
+}
+
+// machine returns the platform.machine() of the platform.
+func (p Platform) machine() string {
+	switch {
+	case p.GOOS == "windows" && p.GOARCH == "amd64":
+		return "AMD64"
+	case p.GOOS == "linux" && p.GOARCH == "arm64":
+		return "aarch64"
+	case p.GOARCH == "amd64":
+		return "x86_64"
+	}
+	return p.GOARCH
+}
+
+// MarkerEnvironment returns the values of the environment markers of
+// requirements installed for the platform with the given Python version.
+func (p Platform) MarkerEnvironment(pythonVersion string) map[string]string {
+	env := map[string]string{
+		"os_name":                        "posix",
+		"sys_platform":                   p.GOOS,
+		"platform_system":                strings.ToUpper(p.GOOS[:1]) + p.GOOS[1:],
+		"platform_machine":               p.machine(),
+		"python_full_version":            pythonVersion,
+		"implementation_name":            "cpython",
+		"implementation_version":         pythonVersion,
+		"platform_python_implementation": "CPython",
+	}
+	if p.GOOS == "windows" {
+		env["os_name"] = "nt"
+		env["sys_platform"] = "win32"
+	}
+	if i := strings.LastIndex(pythonVersion, "."); i > 0 {
+		env["python_version"] = pythonVersion[:i]
+	}
+	return env
+}
+
+// WheelFile is the name, version and compatibility tags of a wheel, read
+// from its file name.
+type WheelFile struct {
+	Name         string
+	Version      string
+	PythonTags   []string
+	ABITags      []string
+	PlatformTags []string
+}
+
+// ParseWheelFilename parses the file name of a wheel, like
+// click-8.1.8-py3-none-any.whl.
+func ParseWheelFilename(filename string) (*WheelFile, error) {
+	stem, ok := strings.CutSuffix(filename, ".whl")
+	parts := strings.Split(stem, "-")
+	if !ok || (len(parts) != 5 && len(parts) != 6) {
+		return nil, fmt.Errorf("invalid wheel file name %s", filename)
+	}
+	n := len(parts)
+	return &WheelFile{
+		Name:         NormalizeName(parts[0]),
+		Version:      parts[1],
+		PythonTags:   strings.Split(parts[n-3], "."),
+		ABITags:      strings.Split(parts[n-2], "."),
+		PlatformTags: strings.Split(parts[n-1], "."),
+	}, nil
+}
+
+// SupportsWheel reports whether a wheel can be installed on the platform
+// with the given Python version.
+func (p Platform) SupportsWheel(wheel *WheelFile, pythonVersion string) bool {
+	major, minor, ok := pythonMinor(pythonVersion)
+	if !ok {
+		return false
+	}
+	python := false
+	for _, py := range wheel.PythonTags {
+		for _, abi := range wheel.ABITags {
+			if pythonTagSupported(py, abi, major, minor) {
+				python = true
+			}
+		}
+	}
+	if !python {
+		return false
+	}
+	for _, tag := range wheel.PlatformTags {
+		if tag == "any" || p.platformTagSupported(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// pythonMinor returns the major and minor number of a Python version.
+func pythonMinor(version string) (int, int, bool) {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+func pythonTagSupported(py, abi string, major, minor int) bool {
+	current := fmt.Sprintf("%d%d", major, minor)
+	switch abi {
+	case "none":
+		if py == fmt.Sprintf("py%d", major) || py == "cp"+current {
+			return true
+		}
+		if v, ok := strings.CutPrefix(py, fmt.Sprintf("py%d", major)); ok {
+			n, err := strconv.Atoi(v)
+			return err == nil && n <= minor
+		}
+	case "abi3":
+		if v, ok := strings.CutPrefix(py, fmt.Sprintf("cp%d", major)); ok {
+			n, err := strconv.Atoi(v)
+			return err == nil && n <= minor
+		}
+	case "cp" + current:
+		return py == "cp"+current
+	}
+	return false
+}
+
+// platformTagSupported reports whether a wheel with the platform tag tag can
+// be installed on the platform. manylinux and macOS wheels built for an
+// older version than the newest in Tags are supported.
+func (p Platform) platformTagSupported(tag string) bool {
+	for _, own := range p.Tags {
+		if tag == own {
+			return true
+		}
+		ownVersion, ownArch, ok := platformVersion(own)
+		if !ok {
+			continue
+		}
+		version, arch, ok := platformVersion(tag)
+		if !ok || platformFamily(tag) != platformFamily(own) {
+			continue
+		}
+		archOK := arch == ownArch
+		if platformFamily(tag) == "macosx" {
+			archOK = archOK || arch == "universal2" || ownArch == "x86_64" && (arch == "intel" || arch == "universal")
+		}
+		if archOK && (version[0] < ownVersion[0] || version[0] == ownVersion[0] && version[1] <= ownVersion[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// platformFamily returns the kind of a platform tag, like manylinux.
+func platformFamily(tag string) string {
+	for _, family := range []string{"manylinux", "macosx"} {
+		if strings.HasPrefix(tag, family) {
+			return family
+		}
+	}
+	return tag
+}
+
+// platformVersion returns the glibc or macOS version and the architecture
+// of a manylinux or macOS platform tag.
+func platformVersion(tag string) ([2]int, string, bool) {
+	legacy := map[string][2]int{"manylinux1": {2, 5}, "manylinux2010": {2, 12}, "manylinux2014": {2, 17}}
+	for prefix, version := range legacy {
+		if arch, ok := strings.CutPrefix(tag, prefix+"_"); ok {
+			return version, arch, true
+		}
+	}
+	var rest string
+	switch {
+	case strings.HasPrefix(tag, "manylinux_"):
+		rest = strings.TrimPrefix(tag, "manylinux_")
+	case strings.HasPrefix(tag, "macosx_"):
+		rest = strings.TrimPrefix(tag, "macosx_")
+	default:
+		return [2]int{}, "", false
+	}
+	parts := strings.SplitN(rest, "_", 3)
+	if len(parts) != 3 {
+		return [2]int{}, "", false
+	}
+	x, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return [2]int{}, "", false
+	}
+	y, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return [2]int{}, "", false
+	}
+	return [2]int{x, y}, parts[2], true
+}
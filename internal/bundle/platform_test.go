@@ -0,0 +1,85 @@
+package bundle_test
+
+import (
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestPythonVersion(t *testing.T) {
+	version, err := bundle.PythonVersion("v0.0.0-3.14.6-20260610-1")
+	if err != nil || version != "3.14.6" {
+		t.Fatalf("unexpected version %q, %v", version, err)
+	}
+	if _, err := bundle.PythonVersion("v1.0.0"); err == nil {
+		t.Fatal("expected error for a version without a Python version")
+	}
+}
+
+func TestSupportsWheel(t *testing.T) {
+	platforms := map[string]bundle.Platform{}
+	for _, p := range bundle.PLATFORMS {
+		platforms[p.String()] = p
+	}
+	cases := []struct {
+		wheel     string
+		platforms []string
+	}{
+		{"click-8.1.8-py3-none-any.whl", []string{"darwin-amd64", "darwin-arm64", "linux-amd64", "linux-arm64", "windows-amd64"}},
+		{"PyYAML-6.0.2-cp314-cp314-manylinux_2_17_x86_64.manylinux2014_x86_64.whl", []string{"linux-amd64"}},
+		{"numpy-2.3.0-cp314-cp314-manylinux_2_34_aarch64.whl", nil},
+		{"numpy-2.3.0-cp313-cp313-manylinux_2_28_aarch64.whl", nil},
+		{"cryptography-45.0.0-cp311-abi3-manylinux_2_28_aarch64.whl", []string{"linux-arm64"}},
+		{"cffi-1.17.1-cp314-cp314-macosx_10_13_universal2.whl", []string{"darwin-amd64", "darwin-arm64"}},
+		{"cffi-1.17.1-cp314-cp314-macosx_14_0_arm64.whl", nil},
+		{"cffi-1.17.1-cp314-cp314-win_amd64.whl", []string{"windows-amd64"}},
+		{"cffi-1.17.1-cp314-cp314-win32.whl", nil},
+	}
+	for _, c := range cases {
+		wheel, err := bundle.ParseWheelFilename(c.wheel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, p := range platforms {
+			want := false
+			for _, supported := range c.platforms {
+				want = want || supported == name
+			}
+			if got := p.SupportsWheel(wheel, "3.14.6"); got != want {
+				t.Errorf("%s on %s: got %v, want %v", c.wheel, name, got, want)
+			}
+		}
+	}
+
+	if _, err := bundle.ParseWheelFilename("click-8.1.8.tar.gz"); err == nil {
+		t.Fatal("expected error for an sdist")
+	}
+}
+
+func TestMarkerEnvironment(t *testing.T) {
+	for _, p := range bundle.PLATFORMS {
+		if p.String() != "windows-amd64" {
+			continue
+		}
+		env := p.MarkerEnvironment("3.14.6")
+		if env["sys_platform"] != "win32" || env["platform_system"] != "Windows" || env["os_name"] != "nt" || env["python_version"] != "3.14" {
+			t.Fatalf("unexpected environment %v", env)
+		}
+	}
+}
+
+func TestSelectPlatforms(t *testing.T) {
+	platforms, err := bundle.SelectPlatforms([]string{"windows-amd64", "linux-amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(platforms) != 2 || platforms[0].String() != "linux-amd64" || platforms[1].String() != "windows-amd64" {
+		t.Fatalf("unexpected platforms %v", platforms)
+	}
+	if platforms, err := bundle.SelectPlatforms(nil); err != nil || len(platforms) != len(bundle.PLATFORMS) {
+		t.Fatalf("expected every platform, got %v, %v", platforms, err)
+	}
+	if _, err := bundle.SelectPlatforms([]string{"linux-386"}); err == nil {
+		t.Fatal("expected error for an unknown platform")
+	}
+}
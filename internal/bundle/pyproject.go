@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/BurntSushi/toml"
 )
@@ -11,40 +12,233 @@ import (
 const DEFAULT_BUNDLE_DIR = ".pybundler"
 
 type ProjectSection struct {
-	Name        string                       `toml:"name"`
-	Version     string                       `toml:"version"`
-	Scripts     map[string]string            `toml:"scripts"`
-	GuiScripts  map[string]string            `toml:"gui-scripts"`
-	EntryPoints map[string]map[string]string `toml:"entry-points"`
+	Name           string                       `toml:"name"`
+	Version        string                       `toml:"version"`
+	Dynamic        []string                     `toml:"dynamic"`
+	Description    string                       `toml:"description"`
+	RequiresPython string                       `toml:"requires-python"`
+	Readme         Readme                       `toml:"readme"`
+	Authors        []Author                     `toml:"authors"`
+	License        License                      `toml:"license"`
+	URLs           map[string]string            `toml:"urls"`
+	Scripts        map[string]string            `toml:"scripts"`
+	GuiScripts     map[string]string            `toml:"gui-scripts"`
+	EntryPoints    map[string]map[string]string `toml:"entry-points"`
+
+	OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+}
+
+// CommandConfig holds the [tool.pybundler.commands.<name>] metadata of a
+// single command.
+type CommandConfig struct {
+	Short   string   `toml:"short"`
+	Long    string   `toml:"long"`
+	Aliases []string `toml:"aliases"`
+	Hidden  bool     `toml:"hidden"`
+	Group   string   `toml:"group"`
+	Order   *int     `toml:"order"`
+
+	// Introspect allows calling a plain function entry point while
+	// bundling to capture the argparse parser it builds.
+	Introspect bool `toml:"introspect"`
+}
+
+// AppConfig holds a [tool.pybundler.apps] entry. An app is either given as
+// an object reference, which is served as an ASGI application, or as a
+// table with the reference and the kind of application.
+type AppConfig struct {
+	App  string
+	Kind string
+}
+
+func (a *AppConfig) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		a.App = v
+	case map[string]any:
+		var err error
+		a.App, err = tomlString(v, "app")
+		if err != nil {
+			return fmt.Errorf("app: %v", err)
+		}
+		a.Kind, err = tomlString(v, "kind")
+		if err != nil {
+			return fmt.Errorf("app: %v", err)
+		}
+	default:
+		return fmt.Errorf("app must be a string or a table, got %T", data)
+	}
+	if a.Kind == "" {
+		a.Kind = "asgi"
+	}
+	return nil
+}
+
+// PyBundlerSection holds the [tool.pybundler] configuration.
+type PyBundlerSection struct {
+	CommandSeparator *string                  `toml:"command-separator"`
+	Commands         map[string]CommandConfig `toml:"commands"`
+	Apps             map[string]AppConfig     `toml:"apps"`
+
+	// HelpReadme uses the readme of the project as the long description of
+	// the root command.
+	HelpReadme bool `toml:"help-readme"`
+
+	// Extras and Groups are the optional dependency extras and dependency
+	// groups installed in the bundle, in addition to the extras required by
+	// entry points. AllExtras installs every extra.
+	Extras    []string `toml:"extras"`
+	AllExtras bool     `toml:"all-extras"`
+	Groups    []string `toml:"groups"`
+
+	// GoEmbedPython overrides the go-embed-python version pinned by the
+	// release, which also selects the embedded Python version.
+	GoEmbedPython string `toml:"go-embed-python"`
+
+	// Platforms are the platforms the bundle is packaged for, like
+	// linux-amd64. Every platform in PLATFORMS is packaged by default.
+	Platforms []string `toml:"platforms"`
+
+	// BuildSdists are the packages whose wheels may be built from their
+	// source distributions for the host platform.
+	BuildSdists []string `toml:"build-sdists"`
+
+	// Optimize is the optimization level the bytecode of the bundle is
+	// compiled with, like python -O. The bundle runs with the same level.
+	Optimize int `toml:"optimize"`
+
+	IndexSection
+}
+
+type ToolSection struct {
+	PyBundler PyBundlerSection `toml:"pybundler"`
+	UV        UVSection        `toml:"uv"`
 }
 
 type PyProject struct {
-	Project ProjectSection `toml:"project"`
+	Project          ProjectSection   `toml:"project"`
+	DependencyGroups map[string][]any `toml:"dependency-groups"`
+	Tool             ToolSection      `toml:"tool"`
 }
 
 func NewPyProject(p string) (*PyProject, error) {
-	if _, err := os.Stat(filepath.Join(p, "pyproject.toml")); err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("pyproject.toml not found in %s", p)
+	fp := filepath.Join(p, "pyproject.toml")
+	if _, err := os.Stat(fp); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reading pyproject.toml from %s: %v", fp, err)
+		}
+		// Projects built by setuptools alone have no pyproject.toml. Their
+		// metadata is read from the built wheel.
+		for _, name := range []string{"setup.py", "setup.cfg"} {
+			if _, err := os.Stat(filepath.Join(p, name)); err == nil {
+				return &PyProject{}, nil
+			}
 		}
+		return nil, fmt.Errorf("pyproject.toml not found in %s", p)
 	}
 
-	fp := filepath.Join(p, "pyproject.toml")
 	pt, err := os.ReadFile(fp)
 	if err != nil {
 		return nil, fmt.Errorf("reading pyproject.toml from %s", fp)
 	}
 
 	var pyproject PyProject
-	_, err = toml.Decode(string(pt), &pyproject)
+	md, err := toml.Decode(string(pt), &pyproject)
 	if err != nil {
-		return nil, fmt.Errorf("unmarshalling pyproject.toml from %s", fp)
+		return nil, fmt.Errorf("unmarshalling pyproject.toml from %s: %v", fp, err)
+	}
+	if !md.IsDefined("project") {
+		// The metadata is declared by the build backend, like in
+		// [tool.poetry], and read from the built wheel.
+		return &pyproject, nil
 	}
 	if pyproject.Project.Name == "" {
 		return nil, fmt.Errorf("project name not found in %s", fp)
 	}
-	if pyproject.Project.Version == "" {
+	if pyproject.Project.Version == "" && !pyproject.IsDynamic("version") {
 		return nil, fmt.Errorf("project version not found in %s", fp)
 	}
 	return &pyproject, nil
 }
+
+// IsDynamic reports whether field is listed in [project] dynamic, so that
+// it is provided by the build backend.
+func (p *PyProject) IsDynamic(field string) bool {
+	return slices.Contains(p.Project.Dynamic, field)
+}
+
+// HasStaticMetadata reports whether the name, version, description and
+// entry points of the project are all declared in [project]. Otherwise they
+// are read from the metadata of the built wheel with SetWheelMetadata.
+func (p *PyProject) HasStaticMetadata() bool {
+	if p.Project.Name == "" {
+		return false
+	}
+	for _, field := range p.Project.Dynamic {
+		if _, ok := wheelMetadataFields[field]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// wheelMetadataFields sets the [project] fields that are taken from the
+// metadata of a built wheel.
+var wheelMetadataFields = map[string]func(p, wheel *ProjectSection){
+	"version":               func(p, wheel *ProjectSection) { p.Version = wheel.Version },
+	"description":           func(p, wheel *ProjectSection) { p.Description = wheel.Description },
+	"readme":                func(p, wheel *ProjectSection) { p.Readme = wheel.Readme },
+	"authors":               func(p, wheel *ProjectSection) { p.Authors = wheel.Authors },
+	"license":               func(p, wheel *ProjectSection) { p.License = wheel.License },
+	"urls":                  func(p, wheel *ProjectSection) { p.URLs = wheel.URLs },
+	"scripts":               func(p, wheel *ProjectSection) { p.Scripts = wheel.Scripts },
+	"gui-scripts":           func(p, wheel *ProjectSection) { p.GuiScripts = wheel.GuiScripts },
+	"entry-points":          func(p, wheel *ProjectSection) { p.EntryPoints = wheel.EntryPoints },
+	"optional-dependencies": func(p, wheel *ProjectSection) { p.OptionalDependencies = wheel.OptionalDependencies },
+}
+
+// SetWheelMetadata replaces the dynamic fields of the project with the
+// metadata of the built wheel. Projects without a [project] table take all
+// of their metadata from the wheel.
+func (p *PyProject) SetWheelMetadata(wheel *ProjectSection) {
+	if p.Project.Name == "" {
+		p.Project = *wheel
+		return
+	}
+	for _, field := range p.Project.Dynamic {
+		if set, ok := wheelMetadataFields[field]; ok {
+			set(&p.Project, wheel)
+		}
+	}
+}
+
+// HasExtra reports whether the project declares the optional dependency
+// group extra. Names are compared in their normalized form.
+func (p *PyProject) HasExtra(extra string) bool {
+	for name := range p.Project.OptionalDependencies {
+		if NormalizeName(name) == NormalizeName(extra) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasGroup reports whether the project declares the dependency group
+// group. Names are compared in their normalized form.
+func (p *PyProject) HasGroup(group string) bool {
+	for name := range p.DependencyGroups {
+		if NormalizeName(name) == NormalizeName(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandSeparator returns the separator used to split entry point names
+// into nested commands.
+func (p *PyProject) CommandSeparator() string {
+	if p.Tool.PyBundler.CommandSeparator == nil {
+		return DEFAULT_COMMAND_SEPARATOR
+	}
+	return *p.Tool.PyBundler.CommandSeparator
+}
@@ -0,0 +1,277 @@
+"""Print the docstrings and help snapshots of entry points as JSON.
+
+The entry points are passed as a JSON list of [module, attr, prog, call]
+items in the first argument. The result is printed as a JSON list on the
+last line of output. Each item holds the docstring of the entry point and a
+snapshot of its command line interface, either of which is null when
+unavailable.
+
+Click commands and Typer applications are read without calling anything.
+Plain functions are only called when call is set, with no arguments
+until they hand over to a Click command or an argparse parser, which is
+captured instead of parsing the arguments.
+"""
+import argparse
+import importlib
+import inspect
+import json
+import sys
+
+
+def cleandoc(doc):
+    if isinstance(doc, str) and doc.strip():
+        return inspect.cleandoc(doc)
+    return None
+
+
+def resolve(module, attr):
+    obj = importlib.import_module(module)
+    for part in attr.split(".") if attr else []:
+        obj = getattr(obj, part)
+    return obj
+
+
+def docstring(obj):
+    # Click commands and groups.
+    if isinstance(getattr(obj, "help", None), str):
+        return cleandoc(obj.help)
+    # Typer applications.
+    info = getattr(obj, "info", None)
+    if info is not None and hasattr(obj, "registered_commands"):
+        if cleandoc(getattr(info, "help", None)):
+            return cleandoc(info.help)
+        callback = getattr(getattr(obj, "registered_callback", None), "callback", None)
+        if callback is None and len(obj.registered_commands) == 1:
+            callback = obj.registered_commands[0].callback
+        return cleandoc(getattr(callback, "__doc__", None))
+    callback = getattr(obj, "callback", None)
+    if callable(callback):
+        return cleandoc(callback.__doc__)
+    if inspect.isroutine(obj) or inspect.isclass(obj) or inspect.ismodule(obj):
+        return cleandoc(inspect.getdoc(obj))
+    return None
+
+
+def click_command(obj):
+    click = sys.modules.get("click")
+    if click is not None and isinstance(obj, click.Command):
+        return obj
+    typer = sys.modules.get("typer")
+    if typer is not None and isinstance(obj, typer.Typer):
+        return typer.main.get_command(obj)
+    return None
+
+
+class Captured(BaseException):
+    def __init__(self, parser):
+        self.parser = parser
+
+
+def capture(func, prog):
+    """Call func until it parses its arguments and return the parser."""
+
+    def intercept(self, *args, **kwargs):
+        raise Captured(self)
+
+    patched = [
+        (argparse.ArgumentParser, "parse_known_args"),
+        (argparse.ArgumentParser, "parse_known_intermixed_args"),
+    ]
+    click = sys.modules.get("click")
+    if click is not None:
+        for cls in (getattr(click.core, "BaseCommand", None), click.core.Command):
+            if cls is not None and "main" in vars(cls):
+                patched.append((cls, "main"))
+    originals = [(cls, name, vars(cls)[name]) for cls, name in patched if name in vars(cls)]
+    argv = sys.argv
+    try:
+        for cls, name, _ in originals:
+            setattr(cls, name, intercept)
+        sys.argv = [prog]
+        func()
+    except Captured as c:
+        return c.parser
+    except BaseException:
+        return None
+    finally:
+        sys.argv = argv
+        for cls, name, original in originals:
+            setattr(cls, name, original)
+    return None
+
+
+class Flags:
+    """Collects the flags of a command, keeping names and shorthands unique."""
+
+    def __init__(self):
+        self.flags = []
+        self.names = {"help"}
+        self.shorthands = set()
+
+    def add(self, opts, usage, type, default="", hidden=False, secondary=()):
+        longs = [o[2:] for o in opts if o.startswith("--") and len(o) > 2]
+        shorts = [o[1:] for o in opts if len(o) == 2 and o[0] == "-" and o[1] != "-"]
+        names = [(name, type) for name in longs]
+        names += [(o[2:], "") for o in secondary if o.startswith("--") and len(o) > 2]
+        # Options without a long name cannot be registered as flags. They
+        # still work, as arguments are passed on to Python unparsed.
+        for i, (name, typ) in enumerate(names):
+            if name in self.names:
+                continue
+            shorthand = ""
+            if i == 0 and shorts and shorts[0] not in self.shorthands:
+                shorthand = shorts[0]
+                self.shorthands.add(shorthand)
+            self.names.add(name)
+            self.flags.append({
+                "name": name,
+                "shorthand": shorthand,
+                "usage": usage if i == 0 else "",
+                "type": typ,
+                "default": default if i == 0 else "",
+                "hidden": hidden or i > 0,
+            })
+
+
+def default_string(value):
+    if isinstance(value, bool) or value is None:
+        return ""
+    if isinstance(value, (str, int, float)):
+        return str(value)
+    if isinstance(value, (list, tuple)) and all(isinstance(v, (str, int, float)) for v in value):
+        return ",".join(str(v) for v in value)
+    return ""
+
+
+def click_help(text):
+    text = (text or "").split("\f", 1)[0]
+    lines = [line for line in text.splitlines() if line.strip() != "\b"]
+    return cleandoc("\n".join(lines)) or ""
+
+
+def click_snapshot(cmd, name, parent=None):
+    click = sys.modules["click"]
+    ctx = click.Context(cmd, info_name=name, parent=parent)
+    help_option = cmd.get_help_option(ctx)
+    flags = Flags()
+    for param in cmd.get_params(ctx):
+        if param is help_option or not isinstance(param, click.Option):
+            continue
+        is_flag = param.is_flag or param.count
+        flags.add(
+            param.opts,
+            param.help or "",
+            "" if is_flag else param.type.name.lower(),
+            "" if is_flag else default_string(param.default),
+            param.hidden,
+            param.secondary_opts,
+        )
+    long = click_help(cmd.help)
+    short = cmd.short_help or long.split("\n", 1)[0]
+    commands = []
+    if isinstance(cmd, click.Group):
+        for sub in cmd.list_commands(ctx):
+            command = cmd.get_command(ctx, sub)
+            if command is not None:
+                commands.append(click_snapshot(command, sub, ctx))
+    framework = "typer" if type(cmd).__module__.split(".")[0] == "typer" else "click"
+    return {
+        "name": name,
+        "framework": framework,
+        "short": short,
+        "long": long,
+        "usage": " ".join(cmd.collect_usage_pieces(ctx)),
+        "hidden": bool(getattr(cmd, "hidden", False)),
+        "flags": flags.flags,
+        "commands": commands,
+    }
+
+
+def argparse_snapshot(parser, name):
+    flags = Flags()
+    options = [a for a in parser._actions if a.option_strings and not isinstance(a, argparse._HelpAction)]
+    usage = ["[OPTIONS]"] if options else []
+    commands = []
+    for action in parser._actions:
+        if isinstance(action, argparse._HelpAction):
+            continue
+        if isinstance(action, argparse._SubParsersAction):
+            helps = {a.dest: a.help for a in action._choices_actions}
+            seen = set()
+            for sub, subparser in action.choices.items():
+                if id(subparser) in seen:
+                    continue
+                seen.add(id(subparser))
+                snapshot = argparse_snapshot(subparser, sub)
+                if helps.get(sub):
+                    snapshot["short"] = helps[sub]
+                commands.append(snapshot)
+            usage.append("COMMAND [ARGS]...")
+            continue
+        help = action.help or ""
+        if help and help != argparse.SUPPRESS:
+            try:
+                help = help % dict(vars(action), prog=parser.prog)
+            except (KeyError, TypeError, ValueError):
+                pass
+        hidden = help == argparse.SUPPRESS
+        if not action.option_strings:
+            if not hidden:
+                usage.append((action.metavar or action.dest).upper())
+            continue
+        type = ""
+        if action.nargs != 0:
+            type = getattr(action.type, "__name__", "string") if action.type else "string"
+            type = {"str": "string", "int": "integer"}.get(type, type)
+        flags.add(
+            action.option_strings,
+            "" if hidden else help,
+            type,
+            "" if action.nargs == 0 else default_string(action.default),
+            hidden,
+        )
+    long = cleandoc(parser.description) or ""
+    return {
+        "name": name,
+        "framework": "argparse",
+        "short": long.split("\n", 1)[0],
+        "long": long,
+        "usage": " ".join(usage),
+        "hidden": False,
+        "flags": flags.flags,
+        "commands": commands,
+    }
+
+
+def snapshot(obj, prog, call):
+    cmd = click_command(obj)
+    if cmd is None and call and inspect.isfunction(obj):
+        cmd = capture(obj, prog)
+    if isinstance(cmd, argparse.ArgumentParser):
+        return argparse_snapshot(cmd, prog)
+    if cmd is not None and click_command(cmd) is not None:
+        return click_snapshot(cmd, prog)
+    return None
+
+
+def main():
+    results = []
+    for module, attr, prog, call in json.loads(sys.argv[1]):
+        result = {"doc": None, "help": None}
+        try:
+            obj = resolve(module, attr)
+            result["doc"] = docstring(obj)
+        except Exception as e:
+            print(f"{module}:{attr}: {e!r}", file=sys.stderr)
+        else:
+            try:
+                result["help"] = snapshot(obj, prog, call)
+            except Exception as e:
+                print(f"{module}:{attr}: reading help: {e!r}", file=sys.stderr)
+        results.append(result)
+    print()
+    print(json.dumps(results))
+
+
+if __name__ == "__main__":
+    main()
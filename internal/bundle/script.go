@@ -0,0 +1,209 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// scriptBlockPattern matches the inline metadata blocks of a script as
+// specified by PEP 723.
+var scriptBlockPattern = regexp.MustCompile(`(?m)^# /// (?P<type>[a-zA-Z0-9-]+)$\s(?P<content>(^#(| .*)$\s)+)^# ///$`)
+
+// ScriptMetadata holds the `script` inline metadata block of a single-file
+// script.
+// Dependencies are not kept, as they are exported by `uv export --script`.
+type ScriptMetadata struct {
+	RequiresPython string      `toml:"requires-python"`
+	Tool           ToolSection `toml:"tool"`
+}
+
+// ParseScriptMetadata reads the `script` inline metadata block from the
+// source of a script. Scripts without a block have no metadata.
+func ParseScriptMetadata(src []byte) (*ScriptMetadata, error) {
+	var block string
+	found := false
+	for _, m := range scriptBlockPattern.FindAllSubmatch(src, -1) {
+		if string(m[1]) != "script" {
+			continue
+		}
+		if found {
+			return nil, fmt.Errorf("multiple script metadata blocks")
+		}
+		found = true
+		lines := strings.SplitAfter(string(m[2]), "\n")
+		for i, line := range lines {
+			line = strings.TrimPrefix(line, "#")
+			lines[i] = strings.TrimPrefix(line, " ")
+		}
+		block = strings.Join(lines, "")
+	}
+
+	var metadata ScriptMetadata
+	_, err := toml.Decode(block, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling script metadata: %v", err)
+	}
+	return &metadata, nil
+}
+
+// CheckRequiresPython returns an error unless pythonVersion satisfies the
+// requires-python specifier of a script, like ">=3.11,<3.14".
+func CheckRequiresPython(requiresPython string, pythonVersion string) error {
+	if strings.TrimSpace(requiresPython) == "" {
+		return nil
+	}
+	clauses := make([]string, 0)
+	for _, clause := range strings.Split(requiresPython, ",") {
+		clause = strings.TrimSpace(clause)
+		op := clause[:len(clause)-len(strings.TrimLeft(clause, "<>=!~"))]
+		version := strings.TrimSpace(clause[len(op):])
+		if op == "" || version == "" {
+			return fmt.Errorf("invalid requires-python %q", requiresPython)
+		}
+		clauses = append(clauses, "python_full_version "+op+" "+PyString(version))
+	}
+	ok, err := EvaluateMarker(strings.Join(clauses, " and "), map[string]string{"python_full_version": pythonVersion})
+	if err != nil {
+		return fmt.Errorf("invalid requires-python %q: %v", requiresPython, err)
+	}
+	if !ok {
+		return fmt.Errorf("script requires Python %s, but the bundle embeds Python %s; select a go-embed-python version with a matching Python version with --go-embed-python", requiresPython, pythonVersion)
+	}
+	return nil
+}
+
+// ScriptModule returns the name of the module a script is installed as.
+func ScriptModule(file string) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	module := strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	if !isDottedName(module) {
+		return "", fmt.Errorf("script name %q cannot be used as a module name", name)
+	}
+	return module, nil
+}
+
+// NewScriptProject describes a single-file script as a project named after
+// the file, with the script as its only command.
+func NewScriptProject(file string) (*PyProject, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading script %s: %v", file, err)
+	}
+	metadata, err := ParseScriptMetadata(src)
+	if err != nil {
+		return nil, fmt.Errorf("reading metadata of %s: %v", file, err)
+	}
+	module, err := ScriptModule(file)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	pyproject := &PyProject{
+		Project: ProjectSection{
+			Name:           name,
+			RequiresPython: metadata.RequiresPython,
+			Scripts:        map[string]string{name: module},
+		},
+		Tool: metadata.Tool,
+	}
+	doc := scriptDocstring(string(src))
+	short, _, multiline := strings.Cut(doc, "\n")
+	pyproject.Project.Description = strings.TrimSpace(short)
+	if multiline {
+		pyproject.Project.Readme = Readme{Text: doc, ContentType: "text/plain"}
+		pyproject.Tool.PyBundler.HelpReadme = true
+	}
+	return pyproject, nil
+}
+
+// scriptDocstring returns the module docstring of a script. The script is
+// not imported, as scripts usually do their work at the top level.
+func scriptDocstring(src string) string {
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimLeft(line, "rRuU")
+		for _, quote := range []string{`"""`, `'''`} {
+			if !strings.HasPrefix(line, quote) {
+				continue
+			}
+			rest := strings.Join(append([]string{line[len(quote):]}, lines[i+1:]...), "\n")
+			doc, _, ok := strings.Cut(rest, quote)
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(doc)
+		}
+		return ""
+	}
+	return ""
+}
+
+// WriteScriptWheel packages a single-file script as a wheel in dir, so that
+// it is installed next to its dependencies, and returns the path of the
+// wheel.
+func WriteScriptWheel(file, name, dir string) (string, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("reading script %s: %v", file, err)
+	}
+	module, err := ScriptModule(file)
+	if err != nil {
+		return "", err
+	}
+	dist := strings.ReplaceAll(NormalizeName(name), "-", "_")
+	distInfo := dist + "-0.dist-info"
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{module + ".py", src},
+		{distInfo + "/METADATA", []byte("Metadata-Version: 2.1\nName: " + name + "\nVersion: 0\n")},
+		{distInfo + "/WHEEL", []byte("Wheel-Version: 1.0\nGenerator: pybundler\nRoot-Is-Purelib: true\nTag: py3-none-any\n")},
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	var record strings.Builder
+	for _, f := range files {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			return "", err
+		}
+		if _, err := fw.Write(f.content); err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(f.content)
+		fmt.Fprintf(&record, "%s,sha256=%s,%d\n", f.name, base64.RawURLEncoding.EncodeToString(sum[:]), len(f.content))
+	}
+	record.WriteString(distInfo + "/RECORD,,\n")
+	fw, err := w.Create(distInfo + "/RECORD")
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write([]byte(record.String())); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	wheel := filepath.Join(dir, dist+"-0-py3-none-any.whl")
+	err = os.WriteFile(wheel, buf.Bytes(), 0644)
+	if err != nil {
+		return "", fmt.Errorf("writing wheel: %v", err)
+	}
+	return wheel, nil
+}
@@ -0,0 +1,133 @@
+package bundle_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+const testScript = `#!/usr/bin/env python3
+# /// script
+# requires-python = ">=3.11"
+# dependencies = [
+#   "rich>=13",
+# ]
+#
+# [tool.pybundler.commands.my-tool]
+# aliases = ["t"]
+# ///
+"""Print a greeting.
+
+Longer text.
+"""
+print("hello")
+`
+
+func TestParseScriptMetadata(t *testing.T) {
+	metadata, err := bundle.ParseScriptMetadata([]byte(testScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.RequiresPython != ">=3.11" || len(metadata.Tool.PyBundler.Commands) != 1 {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+
+	metadata, err = bundle.ParseScriptMetadata([]byte("print('hello')\n"))
+	if err != nil || metadata.RequiresPython != "" {
+		t.Fatalf("expected empty metadata, got %+v, %v", metadata, err)
+	}
+
+	twice := "# /// script\n# dependencies = []\n# ///\n\n# /// script\n# dependencies = []\n# ///\n"
+	if _, err := bundle.ParseScriptMetadata([]byte(twice)); err == nil {
+		t.Fatal("expected error for multiple script blocks")
+	}
+}
+
+func TestNewScriptProject(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "my-tool.py")
+	err := os.WriteFile(file, []byte(testScript), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pyproject, err := bundle.NewScriptProject(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pyproject.Project.Name != "my-tool" || pyproject.Project.Description != "Print a greeting." {
+		t.Fatalf("unexpected project %q, %q", pyproject.Project.Name, pyproject.Project.Description)
+	}
+	sc, err := bundle.NewCommandCollection(*pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := sc.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.IsRunnable() || !slices.Equal(root.PyArgs, []string{"-m", "my_tool"}) {
+		t.Fatalf("expected the root to run the script, got %v", root.PyArgs)
+	}
+	if !slices.Equal(sc.Scripts.Child("my-tool").Aliases, []string{"t"}) {
+		t.Fatal("expected command metadata from the script block")
+	}
+
+	wheel, err := bundle.WriteScriptWheel(file, pyproject.Project.Name, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(wheel) != "my_tool-0-py3-none-any.whl" {
+		t.Fatalf("unexpected wheel %s", wheel)
+	}
+	metadata, err := bundle.ReadWheelMetadata(wheel)
+	if err != nil || metadata.Name != "my-tool" {
+		t.Fatalf("unexpected wheel metadata %+v, %v", metadata, err)
+	}
+
+	b, err := bundle.New(file, filepath.Join(dir, "out"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Binary != "my-tool" || b.Script != file || b.Path != dir {
+		t.Fatalf("unexpected bundle options %q, %q, %q", b.Binary, b.Script, b.Path)
+	}
+
+	old := strings.Replace(testScript, ">=3.11", ">=3.8,<3.12", 1)
+	err = os.WriteFile(file, []byte(old), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = bundle.New(file, filepath.Join(dir, "old"), false)
+	if err == nil || !strings.Contains(err.Error(), "script requires Python >=3.8,<3.12, but the bundle embeds Python 3.14") {
+		t.Fatalf("expected a requires-python error, got %v", err)
+	}
+}
+
+func TestCheckRequiresPython(t *testing.T) {
+	tests := []struct {
+		requiresPython string
+		ok             bool
+	}{
+		{"", true},
+		{">=3.11", true},
+		{">=3.11, <3.15", true},
+		{"~=3.14", true},
+		{"==3.14.*", true},
+		{"<3.14", false},
+		{"==3.13.*", false},
+		{">=3.12,!=3.14.6", false},
+	}
+	for _, tt := range tests {
+		err := bundle.CheckRequiresPython(tt.requiresPython, "3.14.6")
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckRequiresPython(%q) = %v, want ok %v", tt.requiresPython, err, tt.ok)
+		}
+	}
+	if err := bundle.CheckRequiresPython("3.11", "3.14.6"); err == nil || !strings.Contains(err.Error(), "invalid requires-python") {
+		t.Fatalf("expected an invalid requires-python error, got %v", err)
+	}
+}
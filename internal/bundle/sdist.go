@@ -0,0 +1,156 @@
+package bundle
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WithBuildSdists allows building the wheels of the given packages from
+// their source distributions for the host platform.
+func WithBuildSdists(names ...string) Option {
+	return func(p *PyProject) {
+		p.Tool.PyBundler.BuildSdists = append(p.Tool.PyBundler.BuildSdists, names...)
+	}
+}
+
+// BuiltWheels returns the directory with the wheels built from source
+// distributions, by the hash of the source distribution.
+func (c Cache) BuiltWheels() string {
+	return filepath.Join(c.Dir, "built")
+}
+
+// BuiltWheel is a wheel built from the source distribution of a requirement
+// for a platform.
+type BuiltWheel struct {
+	Requirement *Requirement
+	Platform    Platform
+
+	// Wheel is the file name of the wheel in the output directory, and Hash
+	// its hash.
+	Wheel string
+	Hash  string
+}
+
+// Lines returns the requirements that install the wheel on its platform and
+// the requirement from the index on every other platform.
+func (w BuiltWheel) Lines() []string {
+	env := w.Platform.MarkerEnvironment("")
+	sysPlatform, machine := PyString(env["sys_platform"]), PyString(env["platform_machine"])
+	wheel := &Requirement{Path: w.Wheel, Marker: w.Requirement.Marker, Hashes: []string{w.Hash}}
+	wheel = wheel.WithMarker("sys_platform == " + sysPlatform + " and platform_machine == " + machine)
+	other := w.Requirement.WithMarker("sys_platform != " + sysPlatform + " or platform_machine != " + machine)
+	return []string{wheel.Line(), other.Line()}
+}
+
+// buildSdists builds the wheels of the requirements in matrix that have no
+// wheel for the host platform and are allowed by build-sdists. The source
+// distribution is verified against the locked hashes, and the wheel is built
+// with the embedded Python version and cached by the hash of the source
+// distribution.
+func (bo *BundleOptions) buildSdists(matrix *WheelMatrix, pythonVersion string, verbose bool) ([]BuiltWheel, error) {
+	allowed := make([]string, 0)
+	for _, name := range bo.PyProject.Tool.PyBundler.BuildSdists {
+		allowed = append(allowed, NormalizeName(name))
+	}
+	host, ok := HostPlatform()
+	column := slices.IndexFunc(matrix.Platforms, func(p Platform) bool { return p.String() == host.String() })
+	if len(allowed) == 0 || !ok || column < 0 {
+		return nil, nil
+	}
+	cache := bo.Cache
+	if cache == nil {
+		dir, err := DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		cache = &Cache{Dir: dir}
+	}
+	built := make([]BuiltWheel, 0)
+	for i, req := range matrix.Requirements {
+		if matrix.Cells[i][column] != NO_WHEEL || !slices.Contains(allowed, req.Name) {
+			continue
+		}
+		wheel, err := bo.buildSdist(req, cache, host, pythonVersion, verbose)
+		if err != nil {
+			return nil, fmt.Errorf("building wheel of %s==%s from its sdist: %v", req.Name, req.Version, err)
+		}
+		hash, err := FileHash(filepath.Join(bo.Output, wheel))
+		if err != nil {
+			return nil, err
+		}
+		matrix.Cells[i][column] = BUILT
+		built = append(built, BuiltWheel{Requirement: req, Platform: host, Wheel: wheel, Hash: hash})
+	}
+	return built, nil
+}
+
+// buildSdist downloads the source distribution of req, builds its wheel for
+// the host platform unless it is cached and copies the wheel to the output
+// directory.
+func (bo *BundleOptions) buildSdist(req *Requirement, cache *Cache, host Platform, pythonVersion string, verbose bool) (string, error) {
+	dir, err := os.MkdirTemp("", "pybundler-sdist-")
+	if err != nil {
+		return "", fmt.Errorf("creating sdist directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "requirements.txt")
+	reqs := append(bo.PyProject.Tool.PyBundler.Requirements(), req.String())
+	err = os.WriteFile(input, []byte(strings.Join(reqs, "\n")+"\n"), 0644)
+	if err != nil {
+		return "", fmt.Errorf("writing requirements: %v", err)
+	}
+	slog.Info("Downloading sdist", "requirement", req.Name+"=="+req.Version)
+	_, err = bo.run(bo.Path, verbose, "uv", "tool", "run", "pip", "download", "-r", input, "-d", dir, "--no-deps", "--no-binary=:all:")
+	if err != nil {
+		return "", err
+	}
+	sdists := make([]string, 0)
+	for _, pattern := range []string{"*.tar.gz", "*.zip"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return "", err
+		}
+		sdists = append(sdists, matches...)
+	}
+	if len(sdists) != 1 {
+		return "", fmt.Errorf("expected one sdist, found %d", len(sdists))
+	}
+	hash, err := FileHash(sdists[0])
+	if err != nil {
+		return "", err
+	}
+
+	wheelDir := filepath.Join(cache.BuiltWheels(), strings.TrimPrefix(hash, "--hash=sha256:"))
+	wheels, err := filepath.Glob(filepath.Join(wheelDir, "*.whl"))
+	if err != nil {
+		return "", err
+	}
+	if len(wheels) == 0 {
+		major, minor, _ := pythonMinor(pythonVersion)
+		slog.Info("Building wheel from sdist", "sdist", filepath.Base(sdists[0]), "python", fmt.Sprintf("%d.%d", major, minor))
+		env := append(slices.Clone(bo.Env), host.Environ()...)
+		_, err = RunCmdWith(dir, verbose, CmdOptions{Env: env}, "uv", "build", sdists[0], "--wheel", "--python", fmt.Sprintf("%d.%d", major, minor), "-o", wheelDir)
+		if err != nil {
+			return "", err
+		}
+		wheels, err = filepath.Glob(filepath.Join(wheelDir, "*.whl"))
+		if err != nil {
+			return "", err
+		}
+	} else {
+		slog.Info("Using cached wheel built from sdist", "wheel", filepath.Base(wheels[0]))
+	}
+	if len(wheels) != 1 {
+		return "", fmt.Errorf("expected one wheel in %s, found %d", wheelDir, len(wheels))
+	}
+	wheel := filepath.Base(wheels[0])
+	err = copyFile(wheels[0], filepath.Join(bo.Output, wheel))
+	if err != nil {
+		return "", fmt.Errorf("copying wheel: %v", err)
+	}
+	return wheel, nil
+}
@@ -0,0 +1,55 @@
+package bundle_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestBuiltWheelLines(t *testing.T) {
+	req, ok := bundle.ParseRequirement("legacy==1.0 ; python_version >= '3.10' or sys_platform == 'win32' --hash=sha256:aa --hash=sha256:bb")
+	if !ok {
+		t.Fatal("expected a pinned requirement")
+	}
+	platforms, err := bundle.SelectPlatforms([]string{"linux-arm64", "linux-amd64", "windows-amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := bundle.BuiltWheel{Requirement: req, Platform: platforms[1], Wheel: "legacy-1.0-cp314-cp314-linux_aarch64.whl", Hash: "--hash=sha256:cc"}
+	lines := w.Lines()
+	want := []string{
+		"legacy-1.0-cp314-cp314-linux_aarch64.whl ; (python_version >= '3.10' or sys_platform == 'win32') and (sys_platform == 'linux' and platform_machine == 'aarch64') --hash=sha256:cc",
+		"legacy==1.0 ; (python_version >= '3.10' or sys_platform == 'win32') and (sys_platform != 'linux' or platform_machine != 'aarch64') --hash=sha256:aa --hash=sha256:bb",
+	}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+	for _, p := range platforms {
+		var installed []string
+		for _, line := range lines {
+			spec, _, _ := strings.Cut(line, " --hash=")
+			_, marker, _ := strings.Cut(spec, ";")
+			ok, err := bundle.EvaluateMarker(strings.TrimSpace(marker), p.MarkerEnvironment("3.14.6"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				installed = append(installed, line)
+			}
+		}
+		if len(installed) != 1 || (p.String() == w.Platform.String()) != (installed[0] == lines[0]) {
+			t.Errorf("unexpected requirements for %s: %q", p, installed)
+		}
+	}
+	if req.Marker != "python_version >= '3.10' or sys_platform == 'win32'" {
+		t.Fatalf("expected the requirement to be unchanged, got marker %q", req.Marker)
+	}
+
+	req, _ = bundle.ParseRequirement("legacy==1.0 --hash=sha256:aa")
+	w.Requirement = req
+	if lines := w.Lines(); lines[1] != "legacy==1.0 ; sys_platform != 'linux' or platform_machine != 'aarch64' --hash=sha256:aa" {
+		t.Fatalf("unexpected line without a marker %q", lines[1])
+	}
+}
@@ -0,0 +1,135 @@
+package bundle
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SERVE_COMMAND is the name of the command that serves the applications
+// configured in [tool.pybundler.apps].
+const SERVE_COMMAND = "serve"
+
+// Servers are the Python packages that can serve an application of the
+// given kind, in order of preference.
+var Servers = map[string][]string{
+	"asgi": {"uvicorn"},
+	"wsgi": {"gunicorn", "waitress", "uvicorn"},
+}
+
+// addApps adds a serve command for the applications in apps. A single
+// application is served by the serve command itself, several applications
+// by sub commands named after them.
+func (sc *CommandCollection) addApps(apps map[string]AppConfig) error {
+	for _, name := range slices.Sorted(maps.Keys(apps)) {
+		app := apps[name]
+		origin := PyProjectKey("tool", "pybundler", "apps", name)
+		entry, err := ParseEntryPoint(app.App)
+		if err != nil {
+			return fmt.Errorf("%s: %v", origin, err)
+		}
+		launcher, err := NewServerLauncher(app.Kind, entry)
+		if err != nil {
+			return fmt.Errorf("%s: %v", origin, err)
+		}
+
+		c := sc.Apps
+		if len(apps) > 1 {
+			names := SplitCommandName(name, "")
+			if len(names) != 1 {
+				return fmt.Errorf("%s: invalid app name '%s'", origin, name)
+			}
+			c, err = sc.Apps.Insert(names, entry, origin)
+			if err != nil {
+				return err
+			}
+		} else {
+			c.Entry = entry
+			c.Origin = origin
+		}
+		c.Server = app.Kind
+		c.Prog = name
+		c.PyArgs = []string{"-c", launcher}
+		c.Short = fmt.Sprintf("Serve the %s application %s", strings.ToUpper(app.Kind), entry.Module+":"+entry.Attr)
+	}
+	return nil
+}
+
+// NewServerLauncher returns the Python source that serves the application
+// referenced by ep. The host, port, number of workers and whether to
+// reload on changes are passed as arguments.
+func NewServerLauncher(kind string, ep *EntryPoint) (string, error) {
+	if ep.Attr == "" {
+		return "", fmt.Errorf("application '%s' must reference an object, not a module", ep)
+	}
+	ref := PyString(ep.Module + ":" + ep.Attr)
+	lines := []string{
+		"import importlib, importlib.util, sys",
+		"host, port, workers, reload = sys.argv[1], int(sys.argv[2]), int(sys.argv[3]), sys.argv[4] == 'true'",
+	}
+	switch kind {
+	case "asgi":
+		lines = append(lines,
+			"if importlib.util.find_spec('uvicorn') is None:",
+			"    sys.exit('serving ASGI applications requires uvicorn')",
+			"import uvicorn",
+			fmt.Sprintf("uvicorn.run(%s, host=host, port=port, workers=workers, reload=reload)", ref),
+		)
+	case "wsgi":
+		lines = append(lines,
+			"if importlib.util.find_spec('gunicorn') is not None and sys.platform != 'win32':",
+			"    from gunicorn.app.wsgiapp import run",
+			fmt.Sprintf("    sys.argv = [sys.argv[0], '--bind', f'{host}:{port}', '--workers', str(workers)] + (['--reload'] if reload else []) + [%s]", ref),
+			"    sys.exit(run())",
+			"elif importlib.util.find_spec('waitress') is not None:",
+			"    import signal, waitress",
+			fmt.Sprintf("    app = importlib.import_module(%s)", PyString(ep.Module)),
+		)
+		for _, attr := range strings.Split(ep.Attr, ".") {
+			lines = append(lines, fmt.Sprintf("    app = getattr(app, %s)", PyString(attr)))
+		}
+		lines = append(lines,
+			"    signal.signal(signal.SIGTERM, lambda *_: sys.exit(0))",
+			"    waitress.serve(app, host=host, port=port, threads=max(4, workers))",
+			"elif importlib.util.find_spec('uvicorn') is not None:",
+			"    import uvicorn",
+			fmt.Sprintf("    uvicorn.run(%s, interface='wsgi', host=host, port=port, workers=workers, reload=reload)", ref),
+			"else:",
+			"    sys.exit('serving WSGI applications requires gunicorn, waitress or uvicorn')",
+		)
+	default:
+		return "", fmt.Errorf("unknown application kind '%s', expected asgi or wsgi", kind)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// ServeCommand returns the first command at or below c that serves an
+// application, or nil if there is none.
+func (c *Command) ServeCommand() *Command {
+	var found *Command
+	c.Walk(func(c *Command) {
+		if found == nil && c.Server != "" {
+			found = c
+		}
+	})
+	return found
+}
+
+// CheckServers verifies that every served application can be served by one
+// of the packages in requirements, which holds normalized package names.
+func (sc *CommandCollection) CheckServers(requirements map[string]bool) error {
+	var err error
+	sc.Apps.Walk(func(c *Command) {
+		if c.Server == "" || err != nil {
+			return
+		}
+		for _, server := range Servers[c.Server] {
+			if requirements[server] {
+				return
+			}
+		}
+		err = fmt.Errorf("%s: serving %s applications requires one of %s in the project dependencies", c.Origin, strings.ToUpper(c.Server), strings.Join(Servers[c.Server], ", "))
+	})
+	return err
+}
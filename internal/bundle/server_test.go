@@ -0,0 +1,121 @@
+package bundle_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestNewCommandCollectionApps(t *testing.T) {
+	dir := writePyProject(t, `
+[project]
+name = "my-app"
+version = "1.0.0"
+
+[tool.pybundler.apps]
+api = "app.main:app"
+admin = { app = "app.admin:application", kind = "wsgi" }
+`)
+	pyproject, err := bundle.NewPyProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, err := bundle.NewCommandCollection(*pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := sc.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := root.Child("serve")
+	if serve == nil || serve.IsRunnable() {
+		t.Fatalf("expected a serve group below the root")
+	}
+	api, admin := serve.Child("api"), serve.Child("admin")
+	if api == nil || api.Server != "asgi" || admin == nil || admin.Server != "wsgi" {
+		t.Fatalf("unexpected serve commands %v", commandPaths(serve))
+	}
+	if got := root.ServeCommand(); got != admin {
+		t.Fatalf("expected admin to be the first serve command, got %v", got)
+	}
+
+	err = sc.CheckServers(bundle.RequirementNames([]byte("# comment\nuvicorn[standard]==0.34.0\nfastapi==0.115.12\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sc.CheckServers(bundle.RequirementNames([]byte("fastapi==0.115.12\n")))
+	if err == nil || !strings.Contains(err.Error(), "requires one of") {
+		t.Fatalf("expected missing server error, got %v", err)
+	}
+}
+
+func TestNewCommandCollectionSingleApp(t *testing.T) {
+	pyproject := bundle.PyProject{Project: bundle.ProjectSection{Name: "my-app"}}
+	pyproject.Tool.PyBundler.Apps = map[string]bundle.AppConfig{"api": {App: "app.main:app", Kind: "asgi"}}
+	sc, err := bundle.NewCommandCollection(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := sc.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := root.Child("serve")
+	if root.IsRunnable() || serve == nil || serve.Server != "asgi" || serve.IsGroup() {
+		t.Fatalf("expected the root to have a runnable serve command")
+	}
+
+	for kind, app := range map[string]string{"cgi": "app.main:app", "asgi": "app.main"} {
+		pyproject.Tool.PyBundler.Apps = map[string]bundle.AppConfig{"api": {App: app, Kind: kind}}
+		if _, err := bundle.NewCommandCollection(pyproject); err == nil {
+			t.Errorf("expected error for %s application %s", kind, app)
+		}
+	}
+}
+
+func TestNewServerLauncher(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"uvicorn.py":  "def run(app, **kwargs):\n    print('uvicorn', app, sorted(kwargs.items()))\n",
+		"waitress.py": "def serve(app, **kwargs):\n    print('waitress', app(), sorted(kwargs.items()))\n",
+		"web.py":      "class App:\n    @staticmethod\n    def wsgi():\n        return 'wsgi app'\n",
+	}
+	for name, src := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		kind string
+		want string
+	}{
+		{"asgi", "uvicorn web:App.wsgi [('host', '0.0.0.0'), ('port', 9000), ('reload', False), ('workers', 2)]"},
+		{"wsgi", "waitress wsgi app [('host', '0.0.0.0'), ('port', 9000), ('threads', 4)]"},
+	}
+	for _, c := range cases {
+		launcher, err := bundle.NewServerLauncher(c.kind, &bundle.EntryPoint{Module: "web", Attr: "App.wsgi"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(python, "-c", launcher, "0.0.0.0", "9000", "2", "false")
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", c.kind, err, out)
+		}
+		if got := strings.TrimSpace(string(out)); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.kind, got, c.want)
+		}
+	}
+}
@@ -3,42 +3,101 @@ package bundle
 import (
 	"bytes"
 	"embed"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
+	"strconv"
 	"text/template"
 )
 
 //go:embed templates
 var templates embed.FS
 
-func SaveTemplate(template string, output string, data interface{}) error {
-	parent := filepath.Dir(output)
-	if _, err := os.Stat(parent); err != nil {
-		if os.IsNotExist(err) {
-			os.MkdirAll(parent, os.ModePerm)
-		} else {
-			return fmt.Errorf("creating output directory: %v", err)
-		}
+// templateFuncs is available to every template. Values originating from
+// pyproject.toml must pass through one of these before ending up in
+// generated source.
+var templateFuncs = template.FuncMap{
+	"goString":   strconv.Quote,
+	"goIdent":    GoIdentifier,
+	"goPackage":  GoPackageName,
+	"importPath": ImportPath,
+	"jsonString": jsonString,
+	"goVersion":  func() string { return GO_VERSION },
+}
+
+func jsonString(s string) (string, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", err
 	}
-	slog.Debug("Saving template", "template", template, "output", output)
-	if strings.TrimSpace(output) == "." {
-		output = ""
+	return string(b), nil
+}
+
+// RenderedFile is a rendered template that has not been written to disk yet.
+type RenderedFile struct {
+	Template string
+	Path     string
+	Command  *Command
+	Content  []byte
+}
+
+// RenderedFiles collects the files of a generated project so they can be
+// validated before anything is written to disk.
+type RenderedFiles struct {
+	Root  string
+	Files []*RenderedFile
+}
+
+func (rf *RenderedFiles) Render(template string, output string, c *Command) error {
+	err := rf.RenderFile(template, output, c)
+	if err != nil {
+		return err
 	}
+	rf.Files[len(rf.Files)-1].Command = c
+	return nil
+}
 
+// RenderFile renders a file that does not belong to a command, like go.mod.
+func (rf *RenderedFiles) RenderFile(template string, output string, data any) error {
+	slog.Debug("Rendering template", "template", template, "output", output)
 	f, err := RenderTemplate(template, data)
 	if err != nil {
 		return fmt.Errorf("rendering template: %v", err)
 	}
-	err = os.WriteFile(output, []byte(f), 0644)
-	if err != nil {
-		return fmt.Errorf("writing file: %v", err)
+	rf.Files = append(rf.Files, &RenderedFile{
+		Template: template,
+		Path:     output,
+		Content:  []byte(f),
+	})
+	return nil
+}
+
+func (rf *RenderedFiles) Write() error {
+	for _, f := range rf.Files {
+		slog.Debug("Saving template", "template", f.Template, "output", f.Path)
+		err := os.MkdirAll(filepath.Dir(f.Path), os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("creating output directory: %v", err)
+		}
+		err = os.WriteFile(f.Path, f.Content, 0644)
+		if err != nil {
+			return fmt.Errorf("writing file: %v", err)
+		}
 	}
 	return nil
 }
 
+// Rel returns the path of f relative to the project root.
+func (rf *RenderedFiles) Rel(f *RenderedFile) string {
+	rel, err := filepath.Rel(rf.Root, f.Path)
+	if err != nil {
+		return f.Path
+	}
+	return filepath.ToSlash(rel)
+}
+
 func RenderTemplate(name string, data interface{}) (string, error) {
 	// Read the template file
 	f, err := templates.ReadFile("templates/" + name)
@@ -47,7 +106,7 @@ func RenderTemplate(name string, data interface{}) (string, error) {
 	}
 
 	// Parse the template
-	t, err := template.New(name).Parse(string(f))
+	t, err := template.New(name).Funcs(templateFuncs).Parse(string(f))
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
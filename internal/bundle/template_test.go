@@ -0,0 +1,132 @@
+package bundle_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func TestRenderTemplateEscapesValues(t *testing.T) {
+	use := "x\", Run: nil}\nfunc init() { panic(\"pwned\") } //\\ café"
+	cmd := &bundle.Command{
+		AppName: "my-app",
+		Name:    use,
+		Package: "my_mod",
+		VarName: "MyCmd",
+		Entry:   &bundle.EntryPoint{Module: "app", Attr: "main"},
+		PyArgs:  []string{"-c", "print('\"quoted\"\\n')"},
+	}
+	src, err := bundle.RenderTemplate("command.go.tmpl", cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "command.go", src, 0)
+	if err != nil {
+		t.Fatalf("rendered source does not parse: %v\n%s", err, src)
+	}
+
+	literals := map[string]bool{}
+	funcs := 0
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BasicLit:
+			if n.Kind == token.STRING {
+				s, err := strconv.Unquote(n.Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				literals[s] = true
+			}
+		case *ast.FuncDecl:
+			funcs++
+		}
+		return true
+	})
+	for _, want := range []string{use, cmd.PyArgs[1], "my-app/internal/runner"} {
+		if !literals[want] {
+			t.Errorf("expected string literal %q in rendered source", want)
+		}
+	}
+	if funcs != 1 {
+		t.Errorf("expected only the init function to be declared, found %d functions", funcs)
+	}
+}
+
+func TestRenderRootCommand(t *testing.T) {
+	root := bundle.NewRootCommand("my-app")
+	root.Version = "1.2.3"
+	root.Footer = "\nHomepage: https://example.com\n"
+	root.PyArgs = []string{"-m", "my_app"}
+	src, err := bundle.RenderTemplate("root-with-commands.go.tmpl", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "root.go", src, 0); err != nil {
+		t.Fatalf("rendered source does not parse: %v\n%s", err, src)
+	}
+	version := strings.Index(string(src), "runner.WantsVersion(cmd, args)")
+	run := strings.Index(string(src), "runner.Run(")
+	if version < 0 || version > run {
+		t.Errorf("expected the version flag to be handled before running Python:\n%s", src)
+	}
+	if !strings.Contains(string(src), "runner.SetFooter(rootCmd, ") || strings.Contains(string(src), "SetHelpTemplate") {
+		t.Errorf("expected the footer to be added to the root help only:\n%s", src)
+	}
+
+	root.PyArgs = nil
+	src, err = bundle.RenderTemplate("root-with-commands.go.tmpl", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "root.go", src, 0); err != nil {
+		t.Fatalf("rendered source does not parse: %v\n%s", err, src)
+	}
+	if strings.Contains(string(src), "WantsVersion") || !strings.Contains(string(src), "my-app/internal/runner") {
+		t.Errorf("expected cobra to handle the version flag of a root that is not runnable:\n%s", src)
+	}
+}
+
+func TestRenderTemplateRejectsInvalidImportPath(t *testing.T) {
+	cmd := bundle.NewRootCommand("my app\"")
+	_, err := bundle.RenderTemplate("main.go.tmpl", cmd)
+	if err == nil || !strings.Contains(err.Error(), "invalid import path") {
+		t.Fatalf("expected invalid import path error, got %v", err)
+	}
+}
+
+func TestGoModulePath(t *testing.T) {
+	p, err := bundle.GoModulePath("My_Project.Name")
+	if err != nil || p != "my-project-name" {
+		t.Fatalf("got %q, %v", p, err)
+	}
+	for _, name := range []string{"", "a\"b", "a/b", "-a", "café"} {
+		if _, err := bundle.GoModulePath(name); err == nil {
+			t.Errorf("expected error for %q", name)
+		}
+	}
+}
+
+func TestGoNames(t *testing.T) {
+	cases := []struct{ in, ident, exported, pkg string }{
+		{"my-tool", "my_tool", "MyTool", "my_tool"},
+		{"2fa", "_2fa", "Cmd_2fa", "pkg_2fa"},
+		{"type", "_type", "Type", "pkg_type"},
+		{"my.tool", "my_tool", "My_tool", "my_tool"},
+	}
+	for _, c := range cases {
+		if got := bundle.GoIdentifier(c.in); got != c.ident {
+			t.Errorf("GoIdentifier(%q) = %q, want %q", c.in, got, c.ident)
+		}
+		if got := bundle.GoExportedIdentifier(c.in); got != c.exported {
+			t.Errorf("GoExportedIdentifier(%q) = %q, want %q", c.in, got, c.exported)
+		}
+		if got := bundle.GoPackageName(c.in); got != c.pkg {
+			t.Errorf("GoPackageName(%q) = %q, want %q", c.in, got, c.pkg)
+		}
+	}
+}
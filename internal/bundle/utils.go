@@ -2,33 +2,83 @@ package bundle
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
-
-	"math/rand"
 	"time"
+	"unicode"
 )
 
+// CmdError is returned by RunCmd when a command fails. Stderr holds what the
+// command wrote to its standard error.
+type CmdError struct {
+	Err     error
+	Stderr  string
+	verbose bool
+}
+
+func (e *CmdError) Error() string {
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" && !e.verbose {
+		return fmt.Sprintf("running command: %v\n%s", e.Err, stderr)
+	}
+	return fmt.Sprintf("running command: %v", e.Err)
+}
+
+func (e *CmdError) Unwrap() error {
+	return e.Err
+}
+
 func RunCmd(cwd string, verbose bool, args ...string) ([]byte, error) {
+	return RunCmdWith(cwd, verbose, CmdOptions{}, args...)
+}
+
+// CmdOptions adjust how RunCmdWith runs a command.
+type CmdOptions struct {
+	// Env is added to the environment of the command.
+	Env []string
+
+	// Timeout kills the command when it has not finished in time. Zero
+	// waits for the command.
+	Timeout time.Duration
+}
+
+// RunCmdWith is like RunCmd, with the environment and timeout of opts.
+func RunCmdWith(cwd string, verbose bool, opts CmdOptions, args ...string) ([]byte, error) {
 	if strings.TrimSpace(cwd) == "" {
 		cwd = "."
 	}
 
-	cmd := exec.Command(args[0], args[1:]...)
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
 	cmd.Dir = cwd
-	var stdBuffer bytes.Buffer
-	mw := io.MultiWriter(os.Stdout, &stdBuffer)
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	// Children that keep the output open must not block after a kill.
+	cmd.WaitDelay = time.Second
+	var stdBuffer, errBuffer bytes.Buffer
+	cmd.Stdout = &stdBuffer
+	cmd.Stderr = &errBuffer
 	if verbose {
 		slog.Info("Running command", "args", strings.Join(args, " "))
-		cmd.Stdout = mw
-		cmd.Stderr = mw
+		cmd.Stdout = io.MultiWriter(os.Stdout, &stdBuffer)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &errBuffer)
 	}
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("running command: %v", err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", opts.Timeout)
+		}
+		return nil, &CmdError{Err: err, Stderr: errBuffer.String(), verbose: verbose}
 	}
 	res := stdBuffer.String()
 	if verbose {
@@ -37,24 +87,6 @@ func RunCmd(cwd string, verbose bool, args ...string) ([]byte, error) {
 	return []byte(res), nil
 }
 
-const charset = "abcdefghijklmnopqrstuvwxyz" +
-	"ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-
-var seededRand *rand.Rand = rand.New(
-	rand.NewSource(time.Now().UnixNano()))
-
-func RandomStringWithCharset(length int, charset string) string {
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[seededRand.Intn(len(charset))]
-	}
-	return string(b)
-}
-
-func RandomString(length int) string {
-	return RandomStringWithCharset(length, charset)
-}
-
 func ToPascalCase(s string) string {
 	s = strings.ReplaceAll(s, "_", "-")
 	s = strings.ReplaceAll(s, " ", "-")
@@ -67,6 +99,33 @@ func ToPascalCase(s string) string {
 	return strings.Join(parts, "")
 }
 
+// PyString returns s as a single-quoted Python string literal.
+func PyString(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '\'':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case r > 0x7f && !unicode.IsPrint(r):
+			fmt.Fprintf(&b, `\U%08x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
 func IsEmpty(name string) (bool, error) {
 	f, err := os.Open(name)
 	if err != nil {
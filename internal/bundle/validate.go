@@ -0,0 +1,206 @@
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// generatedPackages are packages of the generated project that only exist
+// after `go generate` has run.
+var generatedPackages = map[string]bool{
+	"internal/data": true,
+}
+
+type renderedPackage struct {
+	name    string
+	file    *RenderedFile
+	exports map[string]bool
+}
+
+type parsedFile struct {
+	*RenderedFile
+	ast *ast.File
+}
+
+// Validate parses every rendered Go file, checks that package names and
+// imports of local packages are consistent with the command tree and
+// formats the sources. Problems are reported with the template and command
+// that produced the offending file together with an excerpt of the source.
+func (rf *RenderedFiles) Validate(modulePath string) error {
+	fset := token.NewFileSet()
+	packages := map[string]*renderedPackage{}
+	parsed := make([]*parsedFile, 0)
+	errs := make([]error, 0)
+
+	for _, f := range rf.Files {
+		if filepath.Ext(f.Path) != ".go" {
+			continue
+		}
+		rel := rf.Rel(f)
+		file, err := parser.ParseFile(fset, rel, f.Content, parser.SkipObjectResolution)
+		if err != nil {
+			var list scanner.ErrorList
+			if errors.As(err, &list) && len(list) > 0 {
+				errs = append(errs, rf.sourceError(f, list[0].Pos, list[0].Msg))
+			} else {
+				errs = append(errs, rf.sourceError(f, token.Position{Filename: rel}, err.Error()))
+			}
+			continue
+		}
+		parsed = append(parsed, &parsedFile{f, file})
+
+		dir := path.Dir(rel)
+		name := file.Name.Name
+		isMain := dir == "." || dir == "generate"
+		if isMain != (name == "main") {
+			errs = append(errs, rf.sourceError(f, fset.Position(file.Name.Pos()), fmt.Sprintf("unexpected package name %s in directory %s", name, dir)))
+			continue
+		}
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &renderedPackage{name: name, file: f, exports: map[string]bool{}}
+			packages[dir] = pkg
+		} else if pkg.name != name {
+			errs = append(errs, rf.sourceError(f, fset.Position(file.Name.Pos()), fmt.Sprintf("package %s conflicts with package %s declared in %s", name, pkg.name, rf.Rel(pkg.file))))
+			continue
+		}
+		for _, decl := range file.Decls {
+			for _, ident := range declaredNames(decl) {
+				if ident.IsExported() {
+					pkg.exports[ident.Name] = true
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, f := range parsed {
+		used := usedSelectors(f.ast)
+		for _, imp := range f.ast.Imports {
+			importPath, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				errs = append(errs, rf.sourceError(f.RenderedFile, fset.Position(imp.Pos()), err.Error()))
+				continue
+			}
+			name := path.Base(importPath)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+
+			var target *renderedPackage
+			if rel, ok := strings.CutPrefix(importPath, modulePath+"/"); ok && !generatedPackages[rel] {
+				target = packages[rel]
+				if target == nil {
+					errs = append(errs, rf.sourceError(f.RenderedFile, fset.Position(imp.Pos()), fmt.Sprintf("import %q is not part of the generated project", importPath)))
+					continue
+				}
+				if target.name == "main" {
+					errs = append(errs, rf.sourceError(f.RenderedFile, fset.Position(imp.Pos()), fmt.Sprintf("import %q is a program, not an importable package", importPath)))
+					continue
+				}
+				if imp.Name == nil {
+					name = target.name
+				}
+			}
+			if name == "_" || name == "." {
+				continue
+			}
+
+			selectors, ok := used[name]
+			if !ok {
+				errs = append(errs, rf.sourceError(f.RenderedFile, fset.Position(imp.Pos()), fmt.Sprintf("%q imported as %s and not used", importPath, name)))
+				continue
+			}
+			if target == nil {
+				continue
+			}
+			for _, sel := range selectors {
+				if !target.exports[sel.Sel.Name] {
+					errs = append(errs, rf.sourceError(f.RenderedFile, fset.Position(sel.Pos()), fmt.Sprintf("%s.%s is not declared by package %s (%s)", name, sel.Sel.Name, target.name, rf.Rel(target.file))))
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, f := range parsed {
+		formatted, err := format.Source(f.Content)
+		if err != nil {
+			return rf.sourceError(f.RenderedFile, token.Position{Filename: rf.Rel(f.RenderedFile)}, err.Error())
+		}
+		f.Content = formatted
+	}
+	return nil
+}
+
+func (rf *RenderedFiles) sourceError(f *RenderedFile, pos token.Position, msg string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s (template %s", pos, msg, f.Template)
+	if f.Command != nil {
+		fmt.Fprintf(&b, ", command %q", f.Command.Name)
+		if f.Command.Origin != "" {
+			fmt.Fprintf(&b, " from %s", f.Command.Origin)
+		}
+	}
+	b.WriteString(")")
+	if pos.Line > 0 {
+		lines := bytes.Split(f.Content, []byte("\n"))
+		for i := max(pos.Line-2, 1); i <= min(pos.Line+1, len(lines)); i++ {
+			marker := " "
+			if i == pos.Line {
+				marker = ">"
+			}
+			fmt.Fprintf(&b, "\n  %s %4d | %s", marker, i, lines[i-1])
+		}
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+func declaredNames(decl ast.Decl) []*ast.Ident {
+	names := make([]*ast.Ident, 0)
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil {
+			names = append(names, d.Name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.ValueSpec:
+				names = append(names, s.Names...)
+			case *ast.TypeSpec:
+				names = append(names, s.Name)
+			}
+		}
+	}
+	return names
+}
+
+// usedSelectors returns the qualified identifiers used in f grouped by the
+// name of the qualifier.
+func usedSelectors(f *ast.File) map[string][]*ast.SelectorExpr {
+	used := map[string][]*ast.SelectorExpr{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				used[x.Name] = append(used[x.Name], sel)
+			}
+		}
+		return true
+	})
+	return used
+}
@@ -0,0 +1,80 @@
+package bundle_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func validateFiles(t *testing.T, files map[string]string) error {
+	t.Helper()
+	root := t.TempDir()
+	rf := &bundle.RenderedFiles{Root: root}
+	for p, content := range files {
+		rf.Files = append(rf.Files, &bundle.RenderedFile{
+			Template: "test.go.tmpl",
+			Path:     filepath.Join(root, p),
+			Command:  &bundle.Command{Name: "hello", Origin: "scripts"},
+			Content:  []byte(content),
+		})
+	}
+	return rf.Validate("app")
+}
+
+func TestValidateAcceptsConsistentProject(t *testing.T) {
+	err := validateFiles(t, map[string]string{
+		"main.go":                 "package main\nimport \"app/cmd\"\nfunc main() { cmd.Execute() }\n",
+		"cmd/root.go":             "package cmd\nimport \"app/internal/hello\"\nvar Root = hello.HelloCmd\nfunc Execute() {}\n",
+		"internal/hello/hello.go": "package hello\nimport \"app/internal/data\"\nvar HelloCmd = data.Data\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateReportsSyntaxErrors(t *testing.T) {
+	err := validateFiles(t, map[string]string{
+		"cmd/root.go": "package cmd\n\nvar Root = \"unterminated\nfunc Execute() {}\n",
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"cmd/root.go:3", "test.go.tmpl", `command "hello" from scripts`, `>    3 | var Root = "unterminated`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateReportsInconsistentPackages(t *testing.T) {
+	cases := map[string]map[string]string{
+		"not part of the generated project": {
+			"cmd/root.go": "package cmd\nimport \"app/internal/missing\"\nvar Root = missing.Cmd\n",
+		},
+		"is not declared by package hello": {
+			"cmd/root.go":             "package cmd\nimport \"app/internal/hello\"\nvar Root = hello.Missing\n",
+			"internal/hello/hello.go": "package hello\nvar HelloCmd = 1\n",
+		},
+		"imported as hello and not used": {
+			"cmd/root.go":             "package cmd\nimport \"app/internal/hello\"\n",
+			"internal/hello/hello.go": "package hello\nvar HelloCmd = 1\n",
+		},
+		"conflicts with package": {
+			"internal/hello/a.go": "package hello\n",
+			"internal/hello/b.go": "package other\n",
+		},
+		"unexpected package name": {
+			"main.go": "package cmd\n",
+		},
+	}
+	for want, files := range cases {
+		t.Run(want, func(t *testing.T) {
+			err := validateFiles(t, files)
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Fatalf("expected error containing %q, got %v", want, err)
+			}
+		})
+	}
+}
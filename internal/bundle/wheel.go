@@ -0,0 +1,213 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BuildWheel builds the wheel of the project at projectPath into dir and
+// returns its path. env is added to the environment of uv.
+func BuildWheel(projectPath, dir string, env []string, verbose bool) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "pybundler-build-")
+	if err != nil {
+		return "", fmt.Errorf("creating build directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	_, err = RunCmdWith(projectPath, verbose, CmdOptions{Env: env}, "uv", "build", "--wheel", "-o", tmpDir)
+	if err != nil {
+		return "", fmt.Errorf("building wheel of %s: %v", projectPath, err)
+	}
+	wheels, err := filepath.Glob(filepath.Join(tmpDir, "*.whl"))
+	if err != nil {
+		return "", err
+	}
+	if len(wheels) != 1 {
+		return "", fmt.Errorf("expected one wheel for %s, found %d", projectPath, len(wheels))
+	}
+	wheel := filepath.Join(dir, filepath.Base(wheels[0]))
+	err = copyFile(wheels[0], wheel)
+	if err != nil {
+		return "", fmt.Errorf("copying wheel: %v", err)
+	}
+	return wheel, nil
+}
+
+// ReadWheelMetadata reads the core metadata and the entry points of a built
+// wheel into a project section.
+func ReadWheelMetadata(wheel string) (*ProjectSection, error) {
+	r, err := zip.OpenReader(wheel)
+	if err != nil {
+		return nil, fmt.Errorf("opening wheel %s: %v", wheel, err)
+	}
+	defer r.Close()
+	project, err := readDistInfo(r)
+	if err != nil {
+		return nil, fmt.Errorf("wheel %s: %v", wheel, err)
+	}
+	return project, nil
+}
+
+// ReadInstalledMetadata reads the core metadata and the entry points of the
+// single distribution installed into dir.
+func ReadInstalledMetadata(dir string) (*ProjectSection, error) {
+	project, err := readDistInfo(os.DirFS(dir))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", dir, err)
+	}
+	return project, nil
+}
+
+// readDistInfo reads the METADATA and entry_points.txt files from the
+// .dist-info directory of fsys.
+func readDistInfo(fsys fs.FS) (*ProjectSection, error) {
+	distInfos, err := fs.Glob(fsys, "*.dist-info")
+	if err != nil {
+		return nil, err
+	}
+	if len(distInfos) != 1 {
+		return nil, fmt.Errorf("expected one .dist-info directory, found %d", len(distInfos))
+	}
+
+	project, err := readFSFile(fsys, path.Join(distInfos[0], "METADATA"), parseCoreMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("reading metadata: %v", err)
+	}
+	groups, err := readFSFile(fsys, path.Join(distInfos[0], "entry_points.txt"), parseEntryPointsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return project, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading entry points: %v", err)
+	}
+	project.Scripts = groups["console_scripts"]
+	project.GuiScripts = groups["gui_scripts"]
+	delete(groups, "console_scripts")
+	delete(groups, "gui_scripts")
+	if len(groups) > 0 {
+		project.EntryPoints = groups
+	}
+	return project, nil
+}
+
+func readFSFile[T any](fsys fs.FS, name string, parse func(io.Reader) (T, error)) (T, error) {
+	rc, err := fsys.Open(name)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer rc.Close()
+	return parse(rc)
+}
+
+// parseCoreMetadata parses a METADATA file as described by the core
+// metadata specification.
+func parseCoreMetadata(r io.Reader) (*ProjectSection, error) {
+	reader := textproto.NewReader(bufio.NewReader(r))
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	body, err := io.ReadAll(reader.R)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &ProjectSection{
+		Name:        header.Get("Name"),
+		Version:     header.Get("Version"),
+		Description: header.Get("Summary"),
+		License: License{
+			Expression: header.Get("License-Expression"),
+			Text:       header.Get("License"),
+		},
+	}
+	if project.Name == "" || project.Version == "" {
+		return nil, fmt.Errorf("name and version are required")
+	}
+
+	readme := strings.TrimSpace(string(body))
+	if readme == "" {
+		readme = strings.TrimSpace(header.Get("Description"))
+	}
+	if readme != "" {
+		project.Readme = Readme{Text: readme, ContentType: header.Get("Description-Content-Type")}
+	}
+
+	if addresses, err := mail.ParseAddressList(header.Get("Author-Email")); err == nil {
+		for _, a := range addresses {
+			project.Authors = append(project.Authors, Author{Name: a.Name, Email: a.Address})
+		}
+	}
+	if author := header.Get("Author"); author != "" && len(project.Authors) == 0 {
+		project.Authors = append(project.Authors, Author{Name: author})
+	}
+
+	if homepage := header.Get("Home-Page"); homepage != "" {
+		project.URLs = map[string]string{"Homepage": homepage}
+	}
+	for _, u := range header.Values("Project-Url") {
+		label, url, ok := strings.Cut(u, ",")
+		if !ok {
+			continue
+		}
+		if project.URLs == nil {
+			project.URLs = map[string]string{}
+		}
+		project.URLs[strings.TrimSpace(label)] = strings.TrimSpace(url)
+	}
+
+	for _, extra := range header.Values("Provides-Extra") {
+		if project.OptionalDependencies == nil {
+			project.OptionalDependencies = map[string][]string{}
+		}
+		project.OptionalDependencies[extra] = nil
+	}
+	return project, nil
+}
+
+// parseEntryPointsFile parses an entry_points.txt file into its groups.
+func parseEntryPointsFile(r io.Reader) (map[string]map[string]string, error) {
+	groups := map[string]map[string]string{}
+	var group map[string]string
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if groups[name] == nil {
+				groups[name] = map[string]string{}
+			}
+			group = groups[name]
+		default:
+			name, value, ok := strings.Cut(line, "=")
+			if !ok || group == nil {
+				return nil, fmt.Errorf("line %d: invalid entry point %q", n, line)
+			}
+			group[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return groups, scanner.Err()
+}
+
+// copyFile copies the file src to dst.
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
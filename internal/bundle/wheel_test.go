@@ -0,0 +1,208 @@
+package bundle_test
+
+import (
+	"archive/zip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+const testMetadata = `Metadata-Version: 2.4
+Name: my-app
+Version: 1.2.3.dev4
+Summary: Does things
+Author-email: Jane Doe <jane@example.com>
+License-Expression: MIT
+Project-URL: Homepage, https://example.com
+Provides-Extra: server
+Description-Content-Type: text/markdown
+
+# My app
+
+Longer text.
+`
+
+const testEntryPoints = `[console_scripts]
+my-app = app.cli:main
+
+[gui_scripts]
+viewer = app.gui:main [server]
+
+[my_app.plugins]
+hello = app.plugins:hello
+`
+
+func writeWheel(t *testing.T, files map[string]string) string {
+	t.Helper()
+	wheel := filepath.Join(t.TempDir(), "my_app-1.2.3.dev4-py3-none-any.whl")
+	f, err := os.Create(wheel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return wheel
+}
+
+func TestReadWheelMetadata(t *testing.T) {
+	wheel := writeWheel(t, map[string]string{
+		"app/__init__.py":                              "",
+		"my_app-1.2.3.dev4.dist-info/METADATA":         testMetadata,
+		"my_app-1.2.3.dev4.dist-info/entry_points.txt": testEntryPoints,
+	})
+	project, err := bundle.ReadWheelMetadata(wheel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.Name != "my-app" || project.Version != "1.2.3.dev4" || project.Description != "Does things" {
+		t.Fatalf("unexpected metadata %q, %q, %q", project.Name, project.Version, project.Description)
+	}
+	if project.Readme.Text != "# My app\n\nLonger text." || project.License.String() != "MIT" {
+		t.Fatalf("unexpected readme %q or license %q", project.Readme.Text, project.License.String())
+	}
+	if len(project.Authors) != 1 || project.Authors[0].String() != "Jane Doe <jane@example.com>" {
+		t.Fatalf("unexpected authors %v", project.Authors)
+	}
+	if project.URLs["Homepage"] != "https://example.com" {
+		t.Fatalf("unexpected urls %v", project.URLs)
+	}
+	if project.Scripts["my-app"] != "app.cli:main" || project.GuiScripts["viewer"] != "app.gui:main [server]" {
+		t.Fatalf("unexpected scripts %v and gui scripts %v", project.Scripts, project.GuiScripts)
+	}
+	if project.EntryPoints["my_app.plugins"]["hello"] != "app.plugins:hello" || len(project.EntryPoints) != 1 {
+		t.Fatalf("unexpected entry points %v", project.EntryPoints)
+	}
+
+	wheel = writeWheel(t, map[string]string{"app/__init__.py": ""})
+	if _, err := bundle.ReadWheelMetadata(wheel); err == nil {
+		t.Fatal("expected error for wheel without metadata")
+	}
+}
+
+func TestSetWheelMetadata(t *testing.T) {
+	wheel := writeWheel(t, map[string]string{
+		"my_app-1.2.3.dev4.dist-info/METADATA":         testMetadata,
+		"my_app-1.2.3.dev4.dist-info/entry_points.txt": testEntryPoints,
+	})
+	metadata, err := bundle.ReadWheelMetadata(wheel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := writePyProject(t, `
+[project]
+name = "my-app"
+description = "Static description"
+dynamic = ["version", "scripts", "optional-dependencies"]
+`)
+	pyproject, err := bundle.NewPyProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pyproject.HasStaticMetadata() {
+		t.Fatal("expected dynamic metadata")
+	}
+	pyproject.SetWheelMetadata(metadata)
+	if pyproject.Project.Version != "1.2.3.dev4" || pyproject.Project.Description != "Static description" {
+		t.Fatalf("unexpected version %q or description %q", pyproject.Project.Version, pyproject.Project.Description)
+	}
+	if len(pyproject.Project.GuiScripts) != 0 || !pyproject.HasExtra("server") {
+		t.Fatalf("expected only scripts and extras to be taken from the wheel")
+	}
+	sc, err := bundle.NewCommandCollection(*pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Scripts.Child("my-app") == nil {
+		t.Fatal("expected my-app script from the wheel")
+	}
+
+	dir = writePyProject(t, "[tool.poetry]\nname = \"my-app\"\n")
+	pyproject, err = bundle.NewPyProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pyproject.HasStaticMetadata() {
+		t.Fatal("expected metadata to be read from the wheel without a [project] table")
+	}
+	pyproject.SetWheelMetadata(metadata)
+	if pyproject.Project.Name != "my-app" || len(pyproject.Project.GuiScripts) != 1 {
+		t.Fatalf("expected all metadata to be taken from the wheel")
+	}
+
+	dir = writePyProject(t, "[project]\nname = \"my-app\"\n")
+	if _, err := bundle.NewPyProject(dir); err == nil {
+		t.Fatal("expected error for missing static version")
+	}
+}
+
+func TestNewFromWheel(t *testing.T) {
+	wheel := writeWheel(t, map[string]string{
+		"my_app-1.2.3.dev4.dist-info/METADATA":         testMetadata,
+		"my_app-1.2.3.dev4.dist-info/entry_points.txt": testEntryPoints,
+	})
+	output := filepath.Join(t.TempDir(), "out")
+	b, err := bundle.NewFromWheel(wheel, output, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Package != "my-app @ file://"+filepath.ToSlash(wheel) || b.Wheel != wheel || b.Output != output {
+		t.Fatalf("unexpected bundle options %q, %q, %q", b.Package, b.Wheel, b.Output)
+	}
+	if b.Commands.Scripts.Child("my-app") == nil || b.Commands.GuiScripts.Child("viewer") == nil {
+		t.Fatal("expected commands from the wheel entry points")
+	}
+}
+
+// TestNewFromPackage installs a package from a local file-based index.
+func TestNewFromPackage(t *testing.T) {
+	if _, err := exec.LookPath("uv"); err != nil {
+		t.Skip("uv not available")
+	}
+	wheel := writeWheel(t, map[string]string{
+		"app/__init__.py":                              "",
+		"my_app-1.2.3.dev4.dist-info/METADATA":         testMetadata,
+		"my_app-1.2.3.dev4.dist-info/WHEEL":            "Wheel-Version: 1.0\nGenerator: test\nRoot-Is-Purelib: true\nTag: py3-none-any\n",
+		"my_app-1.2.3.dev4.dist-info/entry_points.txt": testEntryPoints,
+		"my_app-1.2.3.dev4.dist-info/RECORD":           "",
+	})
+	index := t.TempDir()
+	project := filepath.Join(index, "my-app")
+	err := os.MkdirAll(project, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(wheel, filepath.Join(project, filepath.Base(wheel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := `<html><body><a href="` + filepath.Base(wheel) + `">` + filepath.Base(wheel) + `</a></body></html>`
+	err = os.WriteFile(filepath.Join(project, "index.html"), []byte(html), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("UV_INDEX_URL", "")
+	b, err := bundle.NewFromPackage("my-app==1.2.3.dev4", filepath.Join(t.TempDir(), "out"), false, bundle.WithIndex(bundle.IndexSection{IndexURL: "file://" + filepath.ToSlash(index)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.PyProject.Project.Version != "1.2.3.dev4" || b.Commands.Scripts.Child("my-app") == nil {
+		t.Fatal("expected metadata and commands from the installed package")
+	}
+}
@@ -0,0 +1,330 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// UVSection holds the [tool.uv] configuration used by the bundler.
+type UVSection struct {
+	Workspace *WorkspaceSection `toml:"workspace"`
+}
+
+// WorkspaceSection holds the [tool.uv.workspace] table of a workspace root.
+type WorkspaceSection struct {
+	Members []string `toml:"members"`
+	Exclude []string `toml:"exclude"`
+}
+
+// Workspace is a uv workspace. Members maps the normalized project names of
+// the members to their directories.
+type Workspace struct {
+	Root    string
+	Members map[string]string
+}
+
+// WorkspaceMember is a workspace member bundled together with others.
+type WorkspaceMember struct {
+	Dir       string
+	PyProject *PyProject
+}
+
+// FindWorkspace returns the uv workspace that contains path, or nil when
+// path is not part of a workspace.
+func FindWorkspace(path string) (*Workspace, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		ws, err := readWorkspace(dir)
+		if err != nil || ws != nil {
+			return ws, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func readWorkspace(root string) (*Workspace, error) {
+	fp := filepath.Join(root, "pyproject.toml")
+	pt, err := os.ReadFile(fp)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading pyproject.toml from %s: %v", fp, err)
+	}
+	var pyproject PyProject
+	md, err := toml.Decode(string(pt), &pyproject)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling pyproject.toml from %s: %v", fp, err)
+	}
+	section := pyproject.Tool.UV.Workspace
+	if section == nil {
+		return nil, nil
+	}
+
+	ws := &Workspace{Root: root, Members: map[string]string{}}
+	excluded := map[string]bool{}
+	for _, pattern := range section.Exclude {
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace exclude %q: %v", pattern, err)
+		}
+		for _, m := range matches {
+			excluded[m] = true
+		}
+	}
+	dirs := make([]string, 0)
+	if md.IsDefined("project") {
+		dirs = append(dirs, root)
+	}
+	for _, pattern := range section.Members {
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace member %q: %v", pattern, err)
+		}
+		for _, m := range matches {
+			if _, err := os.Stat(filepath.Join(m, "pyproject.toml")); err == nil && !excluded[m] {
+				dirs = append(dirs, m)
+			}
+		}
+	}
+	for _, dir := range dirs {
+		member, err := NewPyProject(dir)
+		if err != nil {
+			return nil, fmt.Errorf("workspace member %s: %v", dir, err)
+		}
+		ws.Members[NormalizeName(member.Project.Name)] = dir
+	}
+	return ws, nil
+}
+
+// Member returns the directory of the member named name.
+func (ws *Workspace) Member(name string) (string, bool) {
+	dir, ok := ws.Members[NormalizeName(name)]
+	return dir, ok
+}
+
+// HasMembers reports whether every name in names is a member of ws.
+func (ws *Workspace) HasMembers(names []string) bool {
+	for _, name := range names {
+		if _, ok := ws.Member(name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// MergeProjects combines the entry points and pybundler configuration of
+// several workspace members into one project named name. The configuration
+// base of the workspace root applies to every member. A command may only be
+// defined by one member, and members may not set a setting to different
+// values.
+func MergeProjects(name string, base PyBundlerSection, members []*PyProject) (*PyProject, error) {
+	merged := &PyProject{Project: ProjectSection{Name: name}}
+	origins := map[string]string{}
+	err := mergePyBundler(&merged.Tool.PyBundler, base, "the workspace root", origins)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if merged.Project.Name == m.Project.Name {
+			merged.Project.Version = m.Project.Version
+			merged.Project.Description = m.Project.Description
+			merged.Project.URLs = m.Project.URLs
+			merged.Project.Authors = m.Project.Authors
+			merged.Project.License = m.Project.License
+			merged.Project.Readme = m.Project.Readme
+		}
+		tables := []struct {
+			key      string
+			src, dst *map[string]string
+		}{
+			{"scripts", &m.Project.Scripts, &merged.Project.Scripts},
+			{"gui-scripts", &m.Project.GuiScripts, &merged.Project.GuiScripts},
+		}
+		for _, table := range tables {
+			for _, k := range slices.Sorted(maps.Keys(*table.src)) {
+				if other, ok := origins[table.key+"/"+k]; ok {
+					return nil, fmt.Errorf("%s is defined by both %s and %s", PyProjectKey("project", table.key, k), other, m.Project.Name)
+				}
+				origins[table.key+"/"+k] = m.Project.Name
+				if *table.dst == nil {
+					*table.dst = map[string]string{}
+				}
+				(*table.dst)[k] = (*table.src)[k]
+			}
+		}
+		for group, entries := range m.Project.EntryPoints {
+			for k, v := range entries {
+				if other, ok := origins["entry-points/"+group+"/"+k]; ok {
+					return nil, fmt.Errorf("%s is defined by both %s and %s", PyProjectKey("project", "entry-points", group, k), other, m.Project.Name)
+				}
+				origins["entry-points/"+group+"/"+k] = m.Project.Name
+				if merged.Project.EntryPoints == nil {
+					merged.Project.EntryPoints = map[string]map[string]string{}
+				}
+				if merged.Project.EntryPoints[group] == nil {
+					merged.Project.EntryPoints[group] = map[string]string{}
+				}
+				merged.Project.EntryPoints[group][k] = v
+			}
+		}
+		for extra, deps := range m.Project.OptionalDependencies {
+			if merged.Project.OptionalDependencies == nil {
+				merged.Project.OptionalDependencies = map[string][]string{}
+			}
+			merged.Project.OptionalDependencies[extra] = deps
+		}
+
+		err := mergePyBundler(&merged.Tool.PyBundler, m.Tool.PyBundler, m.Project.Name, origins)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// mergePyBundler merges the pybundler configuration src of the project
+// origin into dst. Lists of extras, groups, indexes and packages are
+// combined. origins records which project set each setting, command and
+// app, to report conflicts.
+func mergePyBundler(dst *PyBundlerSection, src PyBundlerSection, origin string, origins map[string]string) error {
+	if src.CommandSeparator != nil {
+		key := PyProjectKey("tool", "pybundler", "command-separator")
+		if dst.CommandSeparator != nil && *dst.CommandSeparator != *src.CommandSeparator {
+			return fmt.Errorf("%s is set to different values by %s and %s", key, origins[key], origin)
+		}
+		if dst.CommandSeparator == nil {
+			origins[key] = origin
+		}
+		dst.CommandSeparator = src.CommandSeparator
+	}
+	if len(src.Platforms) > 0 {
+		key := PyProjectKey("tool", "pybundler", "platforms")
+		if len(dst.Platforms) > 0 && !slices.Equal(slices.Sorted(slices.Values(dst.Platforms)), slices.Sorted(slices.Values(src.Platforms))) {
+			return fmt.Errorf("%s is set to different values by %s and %s", key, origins[key], origin)
+		}
+		if len(dst.Platforms) == 0 {
+			origins[key] = origin
+		}
+		dst.Platforms = src.Platforms
+	}
+	settings := []error{
+		mergeSetting(&dst.HelpReadme, src.HelpReadme, "help-readme", origin, origins),
+		mergeSetting(&dst.AllExtras, src.AllExtras, "all-extras", origin, origins),
+		mergeSetting(&dst.GoEmbedPython, src.GoEmbedPython, "go-embed-python", origin, origins),
+		mergeSetting(&dst.Optimize, src.Optimize, "optimize", origin, origins),
+		mergeSetting(&dst.IndexURL, src.IndexURL, "index-url", origin, origins),
+		mergeSetting(&dst.Cert, src.Cert, "cert", origin, origins),
+	}
+	if err := errors.Join(settings...); err != nil {
+		return err
+	}
+	dst.Extras = appendMissing(dst.Extras, src.Extras...)
+	dst.Groups = appendMissing(dst.Groups, src.Groups...)
+	dst.ExtraIndexURL = appendMissing(dst.ExtraIndexURL, src.ExtraIndexURL...)
+	dst.FindLinks = appendMissing(dst.FindLinks, src.FindLinks...)
+	dst.BuildSdists = appendMissing(dst.BuildSdists, src.BuildSdists...)
+
+	for _, k := range slices.Sorted(maps.Keys(src.Commands)) {
+		key := PyProjectKey("tool", "pybundler", "commands", k)
+		if other, ok := origins[key]; ok {
+			return fmt.Errorf("%s is defined by both %s and %s", key, other, origin)
+		}
+		origins[key] = origin
+		if dst.Commands == nil {
+			dst.Commands = map[string]CommandConfig{}
+		}
+		dst.Commands[k] = src.Commands[k]
+	}
+	for _, k := range slices.Sorted(maps.Keys(src.Apps)) {
+		key := PyProjectKey("tool", "pybundler", "apps", k)
+		if other, ok := origins[key]; ok {
+			return fmt.Errorf("%s is defined by both %s and %s", key, other, origin)
+		}
+		origins[key] = origin
+		if dst.Apps == nil {
+			dst.Apps = map[string]AppConfig{}
+		}
+		dst.Apps[k] = src.Apps[k]
+	}
+	return nil
+}
+
+// mergeSetting sets *dst to src when src is set, unless another project
+// set it to a different value.
+func mergeSetting[T comparable](dst *T, src T, name string, origin string, origins map[string]string) error {
+	var unset T
+	if src == unset {
+		return nil
+	}
+	key := PyProjectKey("tool", "pybundler", name)
+	if *dst != unset && *dst != src {
+		return fmt.Errorf("%s is set to different values by %s and %s", key, origins[key], origin)
+	}
+	if *dst == unset {
+		origins[key] = origin
+	}
+	*dst = src
+	return nil
+}
+
+// appendMissing appends the values of values that are not in s yet.
+func appendMissing(s []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(s, v) {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+// LocalRequirement returns the directory or file of a requirement exported
+// by uv that refers to a local path, like a workspace member or a path
+// dependency, relative to dir.
+func LocalRequirement(line string, dir string) (string, bool) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSpace(strings.TrimPrefix(line, "-e "))
+	line, _, _ = strings.Cut(line, " ;")
+	line, _, _ = strings.Cut(line, " --hash=")
+	var p string
+	switch {
+	case strings.HasPrefix(line, ".") || strings.HasPrefix(line, "/"):
+		p = line
+	case strings.Contains(line, " @ file://"):
+		_, ref, _ := strings.Cut(line, " @ ")
+		u, err := url.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			return "", false
+		}
+		p = u.Path
+		if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+			// Windows drive letters, like /C:/path.
+			p = p[1:]
+		}
+	default:
+		return "", false
+	}
+	p = filepath.FromSlash(p)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+	return p, true
+}
@@ -0,0 +1,139 @@
+package bundle_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jenspederm/pybundler/internal/bundle"
+)
+
+func writeWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(p, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestWorkspace(t *testing.T) {
+	root := writeWorkspace(t, map[string]string{
+		"pyproject.toml":              "[tool.uv.workspace]\nmembers = [\"packages/*\"]\nexclude = [\"packages/old\"]\n",
+		"packages/api/pyproject.toml": "[project]\nname = \"my-api\"\nversion = \"1.0.0\"\n\n[project.scripts]\napi = \"api:main\"\n",
+		"packages/cli/pyproject.toml": "[project]\nname = \"my_cli\"\nversion = \"2.0.0\"\n\n[project.scripts]\ncli = \"cli:main\"\n\n[tool.pybundler.commands.cli]\naliases = [\"c\"]\n",
+		"packages/old/pyproject.toml": "[project]\nname = \"old\"\nversion = \"0.1.0\"\n",
+	})
+
+	ws, err := bundle.FindWorkspace(filepath.Join(root, "packages", "api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws == nil || ws.Root != root || len(ws.Members) != 2 {
+		t.Fatalf("unexpected workspace %+v", ws)
+	}
+	if dir, ok := ws.Member("my.cli"); !ok || dir != filepath.Join(root, "packages", "cli") {
+		t.Fatalf("unexpected member directory %q", dir)
+	}
+	if ws.HasMembers([]string{"my-api", "old"}) {
+		t.Fatal("expected excluded member to be missing")
+	}
+
+	b, err := bundle.NewFromWorkspace(root, []string{"my-cli"}, filepath.Join(t.TempDir(), "out"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Path != filepath.Join(root, "packages", "cli") || len(b.Members) != 0 || b.PyProject.Project.Name != "my_cli" {
+		t.Fatalf("expected a single member to be bundled like a project, got %q", b.Path)
+	}
+
+	b, err = bundle.NewFromWorkspace(root, []string{"my-cli", "my-api"}, filepath.Join(t.TempDir(), "out"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Path != root || len(b.Members) != 2 || b.PyProject.Project.Name != "my_cli" {
+		t.Fatalf("unexpected bundle of several members %q, %d, %q", b.Path, len(b.Members), b.PyProject.Project.Name)
+	}
+	cli, api := b.Commands.Scripts.Child("cli"), b.Commands.Scripts.Child("api")
+	if cli == nil || api == nil || len(cli.Aliases) != 1 {
+		t.Fatalf("expected the scripts and metadata of both members, got %v", commandPaths(b.Commands.Scripts))
+	}
+
+	if _, err := bundle.NewFromWorkspace(root, []string{"old"}, filepath.Join(t.TempDir(), "out"), false); err == nil {
+		t.Fatal("expected error for a package that is not a member")
+	}
+}
+
+func TestMergeProjectsSettings(t *testing.T) {
+	root := writeWorkspace(t, map[string]string{
+		"pyproject.toml":              "[tool.uv.workspace]\nmembers = [\"packages/*\"]\n\n[tool.pybundler]\nhelp-readme = true\nfind-links = [\"wheels\"]\n",
+		"packages/api/pyproject.toml": "[project]\nname = \"my-api\"\nversion = \"1.0.0\"\n\n[project.scripts]\napi = \"api:main\"\n\n[tool.pybundler]\nplatforms = [\"linux-amd64\"]\nindex-url = \"https://pypi.example.com/simple\"\noptimize = 2\n",
+		"packages/cli/pyproject.toml": "[project]\nname = \"my-cli\"\nversion = \"2.0.0\"\n\n[project.scripts]\ncli = \"cli:main\"\n",
+	})
+	b, err := bundle.NewFromWorkspace(root, []string{"my-cli", "my-api"}, filepath.Join(t.TempDir(), "out"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb := b.PyProject.Tool.PyBundler
+	if !slices.Equal(pb.Platforms, []string{"linux-amd64"}) || pb.IndexURL != "https://pypi.example.com/simple" || pb.Optimize != 2 {
+		t.Fatalf("expected the settings of the member, got %+v", pb)
+	}
+	if !pb.HelpReadme || !slices.Equal(pb.FindLinks, []string{filepath.Join(root, "wheels")}) {
+		t.Fatalf("expected the settings of the workspace root, got %+v", pb)
+	}
+}
+
+func TestMergeProjectsConflict(t *testing.T) {
+	cases := map[string][]*bundle.PyProject{
+		"project.scripts.cli is defined by both a and b": {
+			{Project: bundle.ProjectSection{Name: "a", Scripts: map[string]string{"cli": "a:main"}}},
+			{Project: bundle.ProjectSection{Name: "b", Scripts: map[string]string{"cli": "b:main"}}},
+		},
+		"tool.pybundler.commands.cli is defined by both a and b": {
+			{Project: bundle.ProjectSection{Name: "a"}, Tool: bundle.ToolSection{PyBundler: bundle.PyBundlerSection{Commands: map[string]bundle.CommandConfig{"cli": {Short: "a"}}}}},
+			{Project: bundle.ProjectSection{Name: "b"}, Tool: bundle.ToolSection{PyBundler: bundle.PyBundlerSection{Commands: map[string]bundle.CommandConfig{"cli": {Short: "b"}}}}},
+		},
+		"tool.pybundler.optimize is set to different values by a and b": {
+			{Project: bundle.ProjectSection{Name: "a"}, Tool: bundle.ToolSection{PyBundler: bundle.PyBundlerSection{Optimize: 1}}},
+			{Project: bundle.ProjectSection{Name: "b"}, Tool: bundle.ToolSection{PyBundler: bundle.PyBundlerSection{Optimize: 2}}},
+		},
+		"tool.pybundler.platforms is set to different values by the workspace root and a": {
+			{Project: bundle.ProjectSection{Name: "a"}, Tool: bundle.ToolSection{PyBundler: bundle.PyBundlerSection{Platforms: []string{"linux-amd64"}}}},
+		},
+	}
+	for want, members := range cases {
+		_, err := bundle.MergeProjects("a", bundle.PyBundlerSection{Platforms: []string{"darwin-arm64"}}, members)
+		if err == nil || err.Error() != want {
+			t.Errorf("expected %q, got %v", want, err)
+		}
+	}
+}
+
+func TestLocalRequirement(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		line string
+		want string
+	}{
+		{"-e ./packages/lib", filepath.Join(dir, "packages", "lib")},
+		{"../shared ; sys_platform == 'linux'", filepath.Join(filepath.Dir(dir), "shared")},
+		{"lib @ file://" + filepath.ToSlash(filepath.Join(dir, "lib-1.0-py3-none-any.whl")), filepath.Join(dir, "lib-1.0-py3-none-any.whl")},
+		{"click==8.1.8", ""},
+		{"# via my-app", ""},
+	}
+	for _, c := range cases {
+		got, ok := bundle.LocalRequirement(c.line, dir)
+		if ok != (c.want != "") || got != c.want {
+			t.Errorf("%q: got %q, %v, want %q", c.line, got, ok, c.want)
+		}
+	}
+}